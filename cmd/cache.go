@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/platfornow/lash/internal/cache"
+	"github.com/platfornow/lash/internal/config"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "cache <COMMAND>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Manage the local download cache",
+	}
+
+	cmd.AddCommand(newCacheListCmd())
+	cmd.AddCommand(newCacheClearCmd())
+
+	return cmd
+}
+
+func newCacheListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                   "list",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "List cached catalogs, manifests and charts",
+		Example:               "  lash cache list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := defaultCache()
+			if err != nil {
+				return err
+			}
+
+			all, err := c.List()
+			if err != nil {
+				return err
+			}
+
+			values := [][]string{}
+			for _, el := range all {
+				status := "stale"
+				if c.Fresh(&el) {
+					status = "fresh"
+				}
+
+				values = append(values, []string{
+					el.URL,
+					fmt.Sprintf("%d", el.Size),
+					el.FetchedAt.Format(time.RFC3339),
+					status,
+				})
+			}
+
+			log.PrintTable(log.GetInstance(), []string{"URL", "SIZE", "FETCHED", "STATUS"}, values)
+
+			return nil
+		},
+	}
+}
+
+func newCacheClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                   "clear",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "Remove all cached downloads",
+		Example:               "  lash cache clear",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := defaultCache()
+			if err != nil {
+				return err
+			}
+
+			if err := c.Clear(); err != nil {
+				return err
+			}
+
+			log.GetInstance().Donef("cache cleared (%s)", c.Dir())
+
+			return nil
+		},
+	}
+}
+
+func defaultCache() (*cache.Cache, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := cache.DefaultDir(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.New(dir, time.Duration(cfg.CacheTTL)*time.Minute), nil
+}
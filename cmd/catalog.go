@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Machiel/slugify"
+	"github.com/platfornow/lash/internal/catalog"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane/configurations"
+	"github.com/platfornow/lash/internal/crossplane/providers"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newCatalogCmd() *cobra.Command {
+	o := catalogOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "catalog <COMMAND>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Browse the PortalNOW catalog",
+	}
+
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	cmd.PersistentFlags().StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	cmd.PersistentFlags().StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context to use")
+	cmd.PersistentFlags().StringVar(&o.catalogPublicKey, "catalog-public-key", "", "minisign public key used to verify the catalog index signature")
+	cmd.PersistentFlags().StringArrayVar(&o.catalogSources, "catalog-source", []string{}, "additional catalog index as name=location[,priority][,key=publickey] (location can be a URL or a local directory, publickey a minisign public key verifying the index signature)")
+	cmd.PersistentFlags().BoolVar(&o.insecureSkipVerify, "insecure-skip-verify", false, "do not verify catalog signature and manifest digests")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:                   "list",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "List all catalog entries",
+		Example:               "  lash catalog list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.list(cmd.Context(), catalog.All())
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:                   "search <term>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Search catalog entries by name or description",
+		Example:               "  lash catalog search helm",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.list(cmd.Context(), catalog.Matching(args[0]))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:                   "info <name>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Show a catalog entry and its manifests",
+		Example:               "  lash catalog info provider-helm",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.info(cmd.Context(), args[0])
+		},
+	})
+
+	return cmd
+}
+
+type catalogOpts struct {
+	kubeconfig         string
+	kubeconfigContext  string
+	catalogPublicKey   string
+	catalogSources     []string
+	insecureSkipVerify bool
+}
+
+func (o *catalogOpts) fetchOpts() (catalog.FetchOpts, error) {
+	sources, err := catalog.ParseSources(o.catalogSources)
+	if err != nil {
+		return catalog.FetchOpts{}, err
+	}
+
+	return catalog.FetchOpts{
+		PublicKey:          o.catalogPublicKey,
+		Sources:            sources,
+		InsecureSkipVerify: o.insecureSkipVerify,
+	}, nil
+}
+
+// restConfig returns nil when the cluster is not reachable, the
+// installed column is then left unknown.
+func (o *catalogOpts) restConfig() *rest.Config {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
+		return nil
+	}
+
+	rc, err := core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+	if err != nil {
+		return nil
+	}
+
+	return rc
+}
+
+func (o *catalogOpts) list(ctx context.Context, criteria catalog.FilterFunc) error {
+	opts, err := o.fetchOpts()
+	if err != nil {
+		return err
+	}
+
+	all, err := catalog.FilterBy(opts, criteria)
+	if err != nil {
+		return fmt.Errorf("fetching catalog: %w", err)
+	}
+
+	installed := installedPackages(ctx, o.restConfig())
+
+	values := [][]string{}
+	for _, el := range all.Items {
+		values = append(values, []string{
+			el.Name,
+			el.Version,
+			el.Kind(),
+			installed.Version(el),
+			el.Source,
+			el.Description,
+		})
+	}
+
+	log.PrintTable(log.GetInstance(), []string{"NAME", "VERSION", "KIND", "INSTALLED", "SOURCE", "DESCRIPTION"}, values)
+
+	return nil
+}
+
+func (o *catalogOpts) info(ctx context.Context, name string) error {
+	opts, err := o.fetchOpts()
+	if err != nil {
+		return err
+	}
+
+	all, err := catalog.FilterBy(opts, catalog.All())
+	if err != nil {
+		return fmt.Errorf("fetching catalog: %w", err)
+	}
+
+	el := all.Find(slugify.Slugify(name))
+	if el == nil {
+		return fmt.Errorf("package '%s' not found in catalog", name)
+	}
+
+	installed := installedPackages(ctx, o.restConfig())
+
+	log.PrintTable(log.GetInstance(), []string{"FIELD", "VALUE"}, [][]string{
+		{"Name", el.Name},
+		{"Version", el.Version},
+		{"Kind", el.Kind()},
+		{"Installed", installed.Version(*el)},
+		{"Image", el.Image},
+		{"Source", el.Source},
+		{"Description", el.Description},
+		{"Manifest", el.Manifest},
+	})
+
+	data, err := catalog.FetchManifest(el, opts)
+	if err != nil {
+		return err
+	}
+	printManifest(el.Manifest, data)
+
+	if el.Kind() != "provider" {
+		return nil
+	}
+
+	for _, fn := range []string{providers.ControllerConfigFile, providers.ServiceAccountFile, providers.ClusterRoleBindingFile} {
+		data, err := catalog.FetchPackageFile(el, fn, opts)
+		if err != nil {
+			return err
+		}
+		printManifest(fn, data)
+	}
+
+	return nil
+}
+
+func printManifest(name string, data []byte) {
+	l := log.GetInstance()
+	l.WriteString(fmt.Sprintf("# %s\n---\n", name))
+	l.WriteString(strings.TrimSpace(string(data)) + "\n\n")
+}
+
+// installedPackages returns the installed Crossplane providers and
+// configurations indexed by name and by package image repository;
+// nil when the cluster cannot be queried.
+func installedPackages(ctx context.Context, rc *rest.Config) catalog.Installed {
+	if rc == nil {
+		return nil
+	}
+
+	all, err := providers.List(ctx, rc)
+	if err != nil {
+		return nil
+	}
+
+	cfgs, err := configurations.List(ctx, rc)
+	if err != nil {
+		return nil
+	}
+
+	res := catalog.Installed{}
+	for _, el := range append(all, cfgs...) {
+		pkg, _, _ := unstructured.NestedString(el.Object, "spec", "package")
+		res.Add(el.GetName(), pkg)
+	}
+
+	return res
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/platfornow/lash/internal/claims"
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/wait"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// newLogBus returns an event bus printing the log events with l.
+func newLogBus(l log.Logger) eventbus.Bus {
+	bus := eventbus.New()
+
+	events.Subscribe(bus, events.LogHandler(l))
+
+	return bus
+}
+
+// waitForClaim waits, up to the claims phase timeout, for the claim
+// readiness counting the ready composed resources in the wait message and
+// printing the tree when it changes; verbose adds the API versions and
+// namespaces of the resources not ready. On timeout the last tree is printed.
+func waitForClaim(ctx context.Context, rc *rest.Config, resource claims.ManagedResource, bus eventbus.BusPublisher, verbose bool) error {
+	last := ""
+
+	err := claims.Wait(ctx, claims.WaitOpts{
+		RESTConfig: rc,
+		Resource:   resource,
+		Timeout:    waitTimeouts.For(wait.PhaseClaims),
+		ProgressFn: func(tree *claims.Node) {
+			ready, total := tree.Count()
+			bus.Publish(&events.WaitProgress{
+				Phase: wait.PhaseClaims,
+				Object: &events.Object{
+					GVK:       schema.FromAPIVersionAndKind(tree.APIVersion, tree.Kind),
+					Name:      tree.Name,
+					Namespace: tree.Namespace,
+				},
+				Ready: ready,
+				Total: total,
+				Conditions: []events.Condition{
+					{Type: "Synced", Status: tree.Synced},
+					{Type: string(claims.TypeReady), Status: tree.Ready, Message: tree.Message},
+				},
+			})
+
+			buf := strings.Builder{}
+			tree.Render(&buf)
+			if buf.String() == last {
+				return
+			}
+			last = buf.String()
+			bus.Publish(events.NewInfoEvent("%s", last))
+
+			if !verbose {
+				return
+			}
+			for _, el := range tree.Blocking() {
+				bus.Publish(events.NewDebugEvent("%s %s in namespace '%s': %s", el.APIVersion, el, el.Namespace, el.Status()))
+			}
+		},
+	})
+
+	var nre *claims.NotReadyError
+	if errors.As(err, &nre) {
+		buf := strings.Builder{}
+		nre.Tree.Render(&buf)
+
+		bus.Publish(events.NewStopWaitEvent())
+		bus.Publish(events.NewWarningEvent("%s", buf.String()))
+	}
+
+	return err
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/platfornow/lash/internal/clusters"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newClusterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "cluster <COMMAND>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Manage the workload clusters registered on a management cluster",
+	}
+
+	cmd.AddCommand(newClusterAddCmd())
+	cmd.AddCommand(newClusterRemoveCmd())
+	cmd.AddCommand(newClusterListCmd())
+
+	return cmd
+}
+
+// clusterOpts selects the management cluster.
+type clusterOpts struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	argoNamespace     string
+}
+
+func (o *clusterOpts) addFlags(fs *pflag.FlagSet) {
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	fs.StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file of the management cluster")
+	fs.StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context of the management cluster")
+	fs.StringVarP(&o.namespace, "namespace", "n", "landscape-system", "namespace where landscape idp is installed")
+	fs.StringVar(&o.argoNamespace, "argocd-namespace", "argocd", "namespace where Argo CD looks for cluster secrets")
+}
+
+func (o *clusterOpts) restConfig() (*rest.Config, error) {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+}
+
+func newClusterAddCmd() *cobra.Command {
+	o := clusterOpts{}
+	var clusterKubeconfig, clusterContext, server string
+
+	cmd := &cobra.Command{
+		Use:                   "add <NAME>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Register a workload cluster",
+		Example: "  lash cluster add dev --cluster-kubeconfig ~/.kube/dev.yaml\n" +
+			"  lash cluster add kind --cluster-context kind-dev --server https://kind-dev-control-plane:6443",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+				return fmt.Errorf("invalid cluster name '%s': %s", name, strings.Join(errs, ", "))
+			}
+
+			if len(clusterKubeconfig) == 0 {
+				clusterKubeconfig = o.kubeconfig
+			}
+
+			dat, err := os.ReadFile(clusterKubeconfig)
+			if err != nil {
+				return err
+			}
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.StartWait(fmt.Sprintf("registering cluster %s...", name))
+			defer l.StopWait()
+
+			err = clusters.Register(cmd.Context(), clusters.RegisterOpts{
+				RESTConfig:      rc,
+				Name:            name,
+				Kubeconfig:      dat,
+				Context:         clusterContext,
+				Server:          server,
+				Namespace:       o.namespace,
+				ArgoCDNamespace: o.argoNamespace,
+			})
+			if err != nil {
+				return err
+			}
+
+			l.Donef("cluster %s registered", name)
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVar(&clusterKubeconfig, "cluster-kubeconfig", "", "kubeconfig file of the workload cluster (default the management one)")
+	cmd.Flags().StringVar(&clusterContext, "cluster-context", "", "kubeconfig context of the workload cluster (default the current one)")
+	cmd.Flags().StringVar(&server, "server", "", "API server URL of the workload cluster as reachable from the management cluster")
+
+	return cmd
+}
+
+func newClusterRemoveCmd() *cobra.Command {
+	o := clusterOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "remove <NAME>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Unregister a workload cluster",
+		Example:               "  lash cluster remove dev",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.StartWait(fmt.Sprintf("removing cluster %s...", args[0]))
+			defer l.StopWait()
+
+			err = clusters.Unregister(cmd.Context(), clusters.UnregisterOpts{
+				RESTConfig:      rc,
+				Name:            args[0],
+				Namespace:       o.namespace,
+				ArgoCDNamespace: o.argoNamespace,
+			})
+			if err != nil {
+				return err
+			}
+
+			l.Donef("cluster %s removed", args[0])
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newClusterListCmd() *cobra.Command {
+	o := clusterOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "list",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "List the registered workload clusters",
+		Example:               "  lash cluster list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			all, err := clusters.List(cmd.Context(), rc, o.namespace, o.argoNamespace)
+			if err != nil {
+				return err
+			}
+
+			values := [][]string{}
+			for _, el := range all {
+				argo := "no"
+				if el.ArgoCD {
+					argo = "yes"
+				}
+				values = append(values, []string{el.Name, valueOrNone(el.Server), argo})
+			}
+
+			log.PrintTable(log.GetInstance(), []string{"NAME", "SERVER", "ARGOCD"}, values)
+
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+
+	return cmd
+}
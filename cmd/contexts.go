@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/fanout"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/pflag"
+)
+
+// contextsOpts selects the kubeconfig contexts a command fans out to.
+type contextsOpts struct {
+	contexts    []string
+	allContexts bool
+	concurrency int
+}
+
+func (c *contextsOpts) addFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&c.contexts, "contexts", []string{}, "comma-separated list of kubeconfig contexts to run against")
+	fs.BoolVar(&c.allContexts, "all-contexts", false, "run against all the contexts of the kubeconfig")
+	fs.IntVar(&c.concurrency, "concurrency", fanout.DefaultConcurrency, "maximum number of contexts processed at the same time")
+}
+
+// enabled returns true when the command must run against more contexts.
+func (c *contextsOpts) enabled() bool {
+	return c.allContexts || len(c.contexts) > 0
+}
+
+// resolve returns the selected contexts, checking they exist in the kubeconfig.
+func (c *contextsOpts) resolve(kubeconfig string) ([]string, error) {
+	yml, err := os.ReadFile(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := core.ContextNames(yml)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.allContexts {
+		return all, nil
+	}
+
+	known := map[string]bool{}
+	for _, el := range all {
+		known[el] = true
+	}
+
+	for _, el := range c.contexts {
+		if !known[el] {
+			return nil, fmt.Errorf("context '%s' not found in '%s'", el, kubeconfig)
+		}
+	}
+
+	return c.contexts, nil
+}
+
+// fanOut runs fn against each selected context, then prints a summary;
+// fn receives a logger prefixing the messages with the context name.
+func (c *contextsOpts) fanOut(ctx context.Context, kubeconfig string, fn func(ctx context.Context, kubeContext string, l log.Logger) error) error {
+	res, err := c.run(ctx, kubeconfig, fn)
+	if err != nil {
+		return err
+	}
+
+	return summarize(res)
+}
+
+// run calls fn for each selected context with bounded concurrency.
+func (c *contextsOpts) run(ctx context.Context, kubeconfig string, fn func(ctx context.Context, kubeContext string, l log.Logger) error) ([]fanout.Result, error) {
+	contexts, err := c.resolve(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	l := log.GetInstance()
+
+	return fanout.Run(ctx, contexts, c.concurrency, func(ctx context.Context, kubeContext string) error {
+		pl := log.NewPrefixLogger(l, kubeContext)
+
+		err := fn(ctx, kubeContext, pl)
+		if err != nil {
+			pl.Fail(err.Error())
+		}
+		return err
+	}), nil
+}
+
+// summarize prints the outcome of each context, failing if any failed.
+func summarize(res []fanout.Result) error {
+	values := [][]string{}
+	for _, el := range res {
+		status, msg := "ok", ""
+		if el.Err != nil {
+			status, msg = "failed", strings.ReplaceAll(el.Err.Error(), "\n", " ")
+		}
+
+		values = append(values, []string{
+			el.Context,
+			status,
+			el.Duration.Round(time.Second).String(),
+			msg,
+		})
+	}
+
+	log.PrintTable(log.GetInstance(), []string{"CONTEXT", "STATUS", "DURATION", "ERROR"}, values)
+
+	if n := fanout.Failed(res); n > 0 {
+		return fmt.Errorf("%d of %d contexts failed", n, len(res))
+	}
+
+	return nil
+}
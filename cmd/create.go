@@ -34,14 +34,8 @@ func newCreateCmd() *cobra.Command {
 				l.SetLevel(log.DebugLevel)
 			}
 
-			handler := events.LogHandler(l)
 			o.bus = eventbus.New()
-			eids := []eventbus.Subscription{
-				o.bus.Subscribe(events.StartWaitEventID, handler),
-				o.bus.Subscribe(events.StopWaitEventID, handler),
-				o.bus.Subscribe(events.DoneEventID, handler),
-				o.bus.Subscribe(events.DebugEventID, handler),
-			}
+			eids := events.Subscribe(o.bus, events.LogHandler(l))
 			defer func() {
 				for _, e := range eids {
 					o.bus.Unsubscribe(e)
@@ -52,7 +46,7 @@ func newCreateCmd() *cobra.Command {
 				return err
 			}
 
-			return o.run()
+			return o.run(cmd.Context())
 		},
 	}
 
@@ -102,9 +96,7 @@ func (o *createOpts) complete() (err error) {
 	return nil
 }
 
-func (o *createOpts) run() error {
-	ctx := context.TODO()
-
+func (o *createOpts) run(ctx context.Context) error {
 	o.bus.Publish(events.NewStartWaitEvent("finishing cleaning..."))
 
 	o.createRepository(ctx)
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/platfornow/lash/internal/argocd"
+	"github.com/platfornow/lash/internal/claims"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane/compositeresourcedefinitions"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/strvals"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	gitopsPackageName = "gitops.pkg.platformnow.io"
+	gitopsClaimName   = "gitops"
+)
+
+func newGitopsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "gitops <COMMAND>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Manage the GitOps (Argo CD) module",
+	}
+
+	cmd.AddCommand(newGitopsEnableCmd())
+	cmd.AddCommand(newGitopsDisableCmd())
+	cmd.AddCommand(newGitopsStatusCmd())
+
+	return cmd
+}
+
+type gitopsOpts struct {
+	kubeconfig        string
+	kubeconfigContext string
+	name              string
+}
+
+func (o *gitopsOpts) addFlags(fs *pflag.FlagSet) {
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	fs.StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	fs.StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context to use")
+	fs.StringVar(&o.name, "name", gitopsClaimName, "name of the Gitops claim")
+}
+
+func (o *gitopsOpts) restConfig() (*rest.Config, error) {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+}
+
+func newGitopsEnableCmd() *cobra.Command {
+	o := gitopsOpts{}
+	var values []string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:                   "enable",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "Install the GitOps module and wait until it is ready",
+		Example: "  lash gitops enable\n" +
+			"  lash gitops enable --set repoUrl=https://github.com/acme/platform.git",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			xrd, err := compositeresourcedefinitions.Get(ctx, rc, gitopsPackageName)
+			if err != nil {
+				return err
+			}
+			if xrd == nil {
+				return fmt.Errorf("composite resource definition '%s' not found, run 'lash init' first", gitopsPackageName)
+			}
+
+			fields, err := compositeresourcedefinitions.GetSpecFields(xrd)
+			if err != nil {
+				return err
+			}
+
+			inp, err := claimSpec(fields, values)
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			if verbose {
+				if b, err := yaml.Marshal(inp); err == nil {
+					l.Debug(string(b))
+				}
+			}
+
+			module := claims.NewGitops(o.name)
+
+			l.StartWait("installing gitops module claim...")
+			err = claims.ApplyModule(ctx, claims.ModuleOpts{
+				RESTConfig: rc,
+				Data:       inp,
+			}, module)
+			if err != nil {
+				l.StopWait()
+				return err
+			}
+			l.Done("gitops module claim installed")
+
+			err = waitForClaim(ctx, rc, module, newLogBus(l), verbose)
+			l.StopWait()
+			if err != nil {
+				return err
+			}
+			l.Done("gitops module is ready")
+
+			return printArgoEndpoint(ctx, rc, l)
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringSliceVar(&values, "set", []string{}, "claim spec values (i.e. repoUrl=https://...), not prompted")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print verbose output")
+
+	return cmd
+}
+
+// claimSpec returns the claim spec from the values set with flags plus,
+// for the XRD spec fields not set, defaults and prompted values.
+func claimSpec(fields []compositeresourcedefinitions.Field, values []string) (map[string]interface{}, error) {
+	set := map[string]interface{}{}
+	if err := strvals.ParseInto(strings.Join(values, ","), set); err != nil {
+		return nil, err
+	}
+
+	vals, err := promptForMissingFields(fields, set)
+	if err != nil {
+		return nil, err
+	}
+
+	// explicit values win over defaults and prompts
+	res := map[string]interface{}{}
+	if err := strvals.ParseInto(strings.Join(append(vals, values...), ","), res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// promptForMissingFields returns the strvals of the XRD spec fields not in
+// set: defaults for the optional ones, prompted values for the required ones.
+func promptForMissingFields(fields []compositeresourcedefinitions.Field, set map[string]interface{}) ([]string, error) {
+	res := []string{}
+	for _, el := range fields {
+		if hasValue(set, el.Name) {
+			continue
+		}
+
+		if !el.Required {
+			if len(el.Default) != 0 {
+				val, err := defaultFieldValue(el)
+				if err != nil {
+					return res, err
+				}
+				res = append(res, val)
+			}
+			continue
+		}
+
+		res = append(res, promptForFieldValue(el, ""))
+	}
+
+	return res, nil
+}
+
+// hasValue returns true if the dotted path is set in inp.
+func hasValue(inp map[string]interface{}, path string) bool {
+	var cur interface{} = inp
+	for _, el := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if cur, ok = m[el]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func newGitopsDisableCmd() *cobra.Command {
+	o := gitopsOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "disable",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "Remove the GitOps module claim",
+		Example:               "  lash gitops disable",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.StartWait("removing gitops module claim...")
+			defer l.StopWait()
+
+			err = claims.DeleteModule(cmd.Context(), claims.DeleteOpts{
+				RESTConfig: rc,
+				Name:       o.name,
+			}, claims.NewGitops(o.name))
+			if err != nil {
+				return err
+			}
+
+			l.Done("gitops module claim removed")
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newGitopsStatusCmd() *cobra.Command {
+	o := gitopsOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "status",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "Show the GitOps module claims and the Argo CD endpoint",
+		Example:               "  lash gitops status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			all, err := claims.List(ctx, rc, claims.NewGitops(o.name))
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			if len(all) == 0 {
+				l.Info("gitops module not enabled (run 'lash gitops enable')")
+				return nil
+			}
+
+			values := [][]string{}
+			for _, el := range all {
+				values = append(values, []string{
+					el.GetName(),
+					fmt.Sprintf("%t", conditionIsTrue(&el, "Synced")),
+					fmt.Sprintf("%t", conditionIsTrue(&el, "Ready")),
+				})
+			}
+			log.PrintTable(l, []string{"NAME", "SYNCED", "READY"}, values)
+
+			return printArgoEndpoint(ctx, rc, l)
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+// printArgoEndpoint reports where the Argo CD server can be reached.
+func printArgoEndpoint(ctx context.Context, rc *rest.Config, l log.Logger) error {
+	ep, err := argocd.FindEndpoint(ctx, rc)
+	if err != nil {
+		return err
+	}
+	if ep == nil {
+		l.Warn("Argo CD server not found")
+		return nil
+	}
+
+	if len(ep.URL) > 0 {
+		l.Infof("Argo CD: %s", ep.URL)
+		return nil
+	}
+
+	l.Infof("Argo CD is not exposed, use: kubectl port-forward svc/%s -n %s 8080:443", ep.Service, ep.Namespace)
+	return nil
+}
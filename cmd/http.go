@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/platfornow/lash/internal/httputils"
+	"github.com/spf13/pflag"
+)
+
+// httpOpts configure the HTTP client shared by all the commands (catalog
+// downloads, registries and webhooks).
+type httpOpts struct {
+	httpProxy   string
+	httpsProxy  string
+	noProxy     string
+	caFile      string
+	authHeaders map[string]string
+}
+
+var httpClient = &httpOpts{}
+
+func (o *httpOpts) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.httpProxy, "http-proxy", os.Getenv("HTTP_PROXY"), "use the specified HTTP proxy")
+	fs.StringVar(&o.httpsProxy, "https-proxy", os.Getenv("HTTPS_PROXY"), "use the specified HTTPS proxy")
+	fs.StringVar(&o.noProxy, "no-proxy", os.Getenv("NO_PROXY"), "comma-separated list of hosts and domains which do not use the proxy")
+	fs.StringVar(&o.caFile, "ca-file", "", "PEM bundle of additional certificate authorities trusted when downloading and connecting to registries")
+	fs.StringToStringVar(&o.authHeaders, "auth-header", map[string]string{}, "Authorization header sent to a host when downloading and connecting to registries (i.e. registry.corp='Bearer xyz')")
+}
+
+// complete configures the shared HTTP client.
+func (o *httpOpts) complete() error {
+	return httputils.Configure(httputils.ClientOpts{
+		HttpProxy:   o.httpProxy,
+		HttpsProxy:  o.httpsProxy,
+		NoProxy:     o.noProxy,
+		CAFile:      o.caFile,
+		AuthHeaders: o.authHeaders,
+	})
+}
@@ -7,19 +7,26 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/platfornow/lash/internal/catalog"
 	"github.com/platfornow/lash/internal/claims"
+	"github.com/platfornow/lash/internal/config"
 	"github.com/platfornow/lash/internal/core"
 	"github.com/platfornow/lash/internal/crossplane"
 	"github.com/platfornow/lash/internal/crossplane/compositeresourcedefinitions"
 	"github.com/platfornow/lash/internal/crossplane/configurations"
+	"github.com/platfornow/lash/internal/diagnostics"
 	"github.com/platfornow/lash/internal/eventbus"
 	"github.com/platfornow/lash/internal/events"
 	"github.com/platfornow/lash/internal/helm"
 	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/oci"
 	"github.com/platfornow/lash/internal/prompt"
+	"github.com/platfornow/lash/internal/rbac"
+	"github.com/platfornow/lash/internal/record"
 	"github.com/platfornow/lash/internal/strvals"
+	"github.com/platfornow/lash/internal/wait"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -45,25 +52,23 @@ func newInitCmd() *cobra.Command {
 				l.SetLevel(log.DebugLevel)
 			}
 
-			handler := events.LogHandler(l)
-
-			eids := []eventbus.Subscription{
-				o.bus.Subscribe(events.StartWaitEventID, handler),
-				o.bus.Subscribe(events.StopWaitEventID, handler),
-				o.bus.Subscribe(events.DoneEventID, handler),
-				o.bus.Subscribe(events.DebugEventID, handler),
-			}
-			defer func() {
-				for _, e := range eids {
-					o.bus.Unsubscribe(e)
-				}
-			}()
-
 			if err := o.complete(); err != nil {
 				return err
 			}
 
-			return o.run()
+			if o.fleet.enabled() {
+				return o.fleet.fanOut(cmd.Context(), o.kubeconfig, func(ctx context.Context, kubeContext string, l log.Logger) error {
+					oc := o
+					oc.kubeconfigContext = kubeContext
+					oc.promptPrefix = kubeContext
+					oc.diagnosticsDir = diagnostics.Dir(o.diagnosticsDir, kubeContext)
+					oc.bus = eventbus.New()
+
+					return oc.runWithLogger(ctx, l)
+				})
+			}
+
+			return o.runWithLogger(cmd.Context(), l)
 		},
 	}
 
@@ -75,13 +80,21 @@ func newInitCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&o.verbose, "verbose", "v", false, "dump verbose output")
 	cmd.Flags().StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
 	cmd.Flags().StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context to use")
-	cmd.Flags().StringVar(&o.httpProxy, "http-proxy", os.Getenv("HTTP_PROXY"), "use the specified HTTP proxy")
-	cmd.Flags().StringVar(&o.httpsProxy, "https-proxy", os.Getenv("HTTPS_PROXY"), "use the specified HTTPS proxy")
-	cmd.Flags().StringVar(&o.noProxy, "no-proxy", os.Getenv("NO_PROXY"), "comma-separated list of hosts and domains which do not use the proxy")
 	cmd.Flags().StringVar(&o.catalogUrl, "catalog-url", "https://github.com/platformnow/catalog.git", "Gitops URL for the Control Plane")
+	cmd.Flags().StringVar(&o.catalogPublicKey, "catalog-public-key", "", "minisign public key used to verify the catalog index signature")
+	cmd.Flags().StringArrayVar(&o.catalogSources, "catalog-source", []string{}, "additional catalog index as name=location[,priority][,key=publickey] (location can be a URL or a local directory, publickey a minisign public key verifying the index signature)")
+	cmd.Flags().BoolVar(&o.insecureSkipVerify, "insecure-skip-verify", false, "do not verify catalog signature and manifest digests")
+	cmd.Flags().BoolVar(&o.verifyImages, "verify-images", false, "check that package images exist in their registry before installing")
+	cmd.Flags().BoolVar(&o.pinDigests, "pin-digests", false, "install packages by image digest resolved from the registry (implies --verify-images)")
+	cmd.Flags().StringArrayVar(&o.imageRegistries, "image-registry", []string{}, "pull package images from a mirror as from=to (i.e. xpkg.upbound.io=registry.corp/upbound)")
+	cmd.Flags().StringSliceVar(&o.pullSecrets, "package-pull-secret", []string{}, "secret added to the packagePullSecrets of providers and configurations")
+	cmd.Flags().StringVar(&o.rbacMode, "rbac-mode", rbac.ModeAdmin, "permissions of the provider service accounts: minimal (catalog RBAC profile) or admin (cluster-admin)")
+	cmd.Flags().StringVar(&o.diagnosticsDir, "diagnostics-dir", "", "directory where the events, container statuses and logs of a failed wait are saved")
 	cmd.Flags().BoolVar(&o.noCrossplane, "no-crossplane", false, "do not install crossplane")
-	cmd.Flags().BoolVarP(&o.management, "management-cluster", "m", false, "Create a management cluster")
+	cmd.Flags().BoolVarP(&o.management, "management-cluster", "m", false, "Create a management cluster (install the management-plane packages too)")
 	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "landscape-system", "namespace where to install landscape idp")
+	o.fleet.addFlags(cmd.Flags())
+	o.recording.addFlags(cmd.Flags())
 	cmd.Flags().StringSliceVar(&o.values, "set", []string{}, "allows you to define values used in core module")
 	cmd.Flags().MarkHidden("set")
 
@@ -94,61 +107,203 @@ const (
 )
 
 type initOpts struct {
-	kubeconfig        string
-	kubeconfigContext string
-	bus               eventbus.Bus
-	restConfig        *rest.Config
-	namespace         string
-	verbose           bool
-	httpProxy         string
-	httpsProxy        string
-	noProxy           string
-	noCrossplane      bool
-	management        bool
-	catalogUrl        string
-	values            []string
+	kubeconfig         string
+	kubeconfigContext  string
+	bus                eventbus.Bus
+	restConfig         *rest.Config
+	namespace          string
+	verbose            bool
+	noCrossplane       bool
+	management         bool
+	catalogUrl         string
+	catalogPublicKey   string
+	catalogSources     []string
+	sources            []catalog.Source
+	insecureSkipVerify bool
+	verifyImages       bool
+	pinDigests         bool
+	imageRegistries    []string
+	mirrors            oci.Mirrors
+	pullSecrets        []string
+	webhooks           []config.Webhook
+	crossplaneVersion  string
+	rbacMode           string
+	diagnosticsDir     string
+	fleet              contextsOpts
+	recording          recordOpts
+	promptPrefix       string
+	values             []string
+	record             *record.Record
 }
 
 func (o *initOpts) complete() (err error) {
-	yml, err := os.ReadFile(o.kubeconfig)
+	o.sources, err = catalog.ParseSources(o.catalogSources)
 	if err != nil {
 		return err
 	}
 
-	o.restConfig, err = core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+	o.rbacMode, err = rbac.ParseMode(o.rbacMode)
 	if err != nil {
 		return err
 	}
 
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	// flags override the mappings of the config file
+	o.mirrors, err = oci.ParseMirrors(append(cfg.ImageRegistries, o.imageRegistries...))
+	if err != nil {
+		return err
+	}
+	o.pullSecrets = append(cfg.PackagePullSecrets, o.pullSecrets...)
+	o.webhooks = cfg.Webhooks
+
 	return nil
 }
 
-func (o *initOpts) run() error {
-	ctx := context.Background()
+// runWithLogger connects to the cluster of the kubeconfig context and
+// runs the installation, rendering the events with the logger.
+func (o *initOpts) runWithLogger(ctx context.Context, l log.Logger) error {
+	suffix := ""
+	if o.fleet.enabled() {
+		suffix = o.kubeconfigContext
+	}
+	stop, err := o.recording.start(o.bus, l, "init", suffix)
+	if err != nil {
+		return err
+	}
+	defer stop()
 
-	if !o.noCrossplane {
-		if err := o.installCrossplane(ctx); err != nil {
-			return err
+	eids := events.Subscribe(o.bus, events.LogHandler(l))
+	defer func() {
+		for _, e := range eids {
+			o.bus.Unsubscribe(e)
 		}
-	}
+	}()
 
-	if err := o.installProviders(ctx); err != nil {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
 		return err
 	}
 
-	if err := o.installPackages(ctx); err != nil {
+	o.restConfig, err = core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+	if err != nil {
 		return err
 	}
 
-	vals, err := o.promptForClaims(ctx)
+	notifiers, err := newNotifiers(ctx, o.webhooks, runTitle("init", o.kubeconfigContext))
 	if err != nil {
 		return err
 	}
 
-	if err := o.applyClaims(ctx, vals); err != nil {
+	return notifyRun(o.bus, notifiers, l, func() error {
+		return o.run(ctx)
+	})
+}
+
+func (o *initOpts) catalogOpts() catalog.FetchOpts {
+	return catalog.FetchOpts{
+		PublicKey:          o.catalogPublicKey,
+		Sources:            o.sources,
+		InsecureSkipVerify: o.insecureSkipVerify,
+	}
+}
+
+// recordInstall adds the package to the install record stored in the cluster
+func (o *initOpts) recordInstall(ctx context.Context, el catalog.PackageInfo) {
+	if o.record == nil {
+		rec, err := record.Load(ctx, o.restConfig, o.namespace)
+		if err != nil {
+			o.bus.Publish(events.NewWarningEvent("loading install record: %s", err.Error()))
+			return
+		}
+		o.record = rec
+	}
+
+	o.record.Add(record.Package{
+		Name:    el.Name,
+		Version: el.Version,
+		Kind:    el.Kind(),
+		Image:   el.Image,
+		Source:  el.Source,
+	})
+
+	if err := record.Save(ctx, o.restConfig, o.record); err != nil {
+		o.bus.Publish(events.NewWarningEvent("saving install record: %s", err.Error()))
+	}
+}
+
+// packageFn returns the spec.package rewrite applied to the installed
+// packages: images are checked and optionally pinned to their digest.
+func (o *initOpts) packageFn() func(context.Context, string) (string, error) {
+	if !o.verifyImages && !o.pinDigests {
+		return nil
+	}
+
+	cli := oci.NewClient()
+
+	return func(ctx context.Context, pkg string) (string, error) {
+		ref, err := oci.ParseReference(pkg)
+		if err != nil {
+			return pkg, err
+		}
+
+		dgst, err := cli.Resolve(ctx, ref)
+		if err != nil {
+			return pkg, fmt.Errorf("verifying image '%s': %w", pkg, err)
+		}
+
+		if o.verbose {
+			o.bus.Publish(events.NewDebugEvent("> image %s resolved to %s", pkg, dgst))
+		}
+
+		if !o.pinDigests {
+			return pkg, nil
+		}
+
+		ref.Tag = ""
+		return ref.WithDigest(dgst).String(), nil
+	}
+}
+
+func (o *initOpts) run(ctx context.Context) error {
+	err := runSteps(ctx, o.bus, "run 'lash init' again to resume (installed components are skipped or updated in place)",
+		step{"crossplane", func(ctx context.Context) error {
+			if !o.noCrossplane {
+				if err := o.installCrossplane(ctx); err != nil {
+					return err
+				}
+			}
+
+			ver, err := crossplane.InstalledVersion(ctx, o.restConfig)
+			if err != nil {
+				return err
+			}
+			o.crossplaneVersion = ver
+
+			return nil
+		}},
+		step{"providers", o.installProviders},
+		step{"packages", o.installPackages},
+		step{"claims", func(ctx context.Context) error {
+			vals, err := o.promptForClaims(ctx)
+			if err != nil {
+				return err
+			}
+
+			return o.applyClaims(ctx, vals)
+		}},
+	)
+	if err != nil {
 		return err
 	}
 
+	if o.management {
+		o.bus.Publish(events.NewDoneEvent("management cluster ready, register workload clusters with: lash cluster add <NAME>"))
+	}
+
 	return nil
 }
 
@@ -181,10 +336,13 @@ func (o *initOpts) installCrossplane(ctx context.Context) error {
 		ChartURL:   url,
 		Namespace:  o.namespace,
 		EventBus:   o.bus,
-		HttpProxy:  o.httpProxy,
-		HttpsProxy: o.httpsProxy,
-		NoProxy:    o.noProxy,
+		HttpProxy:  httpClient.httpProxy,
+		HttpsProxy: httpClient.httpsProxy,
+		NoProxy:    httpClient.noProxy,
 		Verbose:    o.verbose,
+		Timeout:    waitTimeouts.For(wait.PhaseCrossplane),
+
+		DiagnosticsDir: o.diagnosticsDir,
 	})
 	if err != nil {
 		return err
@@ -195,8 +353,23 @@ func (o *initOpts) installCrossplane(ctx context.Context) error {
 	return nil
 }
 
+// forCluster returns criteria extended, on management clusters, to the
+// management-plane entries of the given kind; elsewhere the entries flagged
+// as management are skipped.
+func (o *initOpts) forCluster(kind string, criteria catalog.FilterFunc) catalog.FilterFunc {
+	return func(info catalog.PackageInfo) bool {
+		if info.Management {
+			return o.management && info.Kind() == kind
+		}
+		if criteria(info) {
+			return true
+		}
+		return o.management && info.IsManagement() && info.Kind() == kind
+	}
+}
+
 func (o *initOpts) installProviders(ctx context.Context) error {
-	list, err := catalog.FilterBy(catalog.ForCLI())
+	list, err := catalog.FilterBy(o.catalogOpts(), o.forCluster("provider", catalog.ForCLI()))
 	if err != nil {
 		return fmt.Errorf("fetching providers from catalog: %w", err)
 	}
@@ -207,16 +380,29 @@ func (o *initOpts) installProviders(ctx context.Context) error {
 		err := providers.InstallFromRepo(ctx, providers.InstallOpts{
 			RESTConfig: o.restConfig,
 			Info:       &el,
+			Catalog:    o.catalogOpts(),
 			Namespace:  o.namespace,
 			EventBus:   o.bus,
 			Verbose:    o.verbose,
+			PackageFn:  o.packageFn(),
+
+			ImageRegistries:   o.mirrors,
+			PullSecrets:       o.pullSecrets,
+			CrossplaneVersion: o.crossplaneVersion,
+			RBACMode:          o.rbacMode,
+			HttpProxy:         httpClient.httpProxy,
+			HttpsProxy:        httpClient.httpsProxy,
+			NoProxy:           httpClient.noProxy,
+			Timeout:           waitTimeouts.For(wait.PhaseProviders),
+			DiagnosticsDir:    o.diagnosticsDir,
 		})
 
 		if err != nil {
 			return fmt.Errorf("installing package '%s': %w", el.Name, err)
 		}
 
-		o.bus.Publish(events.NewDoneEvent("Provider %s (%s) installed", el.Name, el.Version))
+		o.bus.Publish(events.NewDoneEvent("Provider %s (%s) installed from %s catalog", el.Name, el.Version, el.Source))
+		o.recordInstall(ctx, el)
 		if o.verbose {
 			o.bus.Publish(events.NewDebugEvent("> image: %s", el.Image))
 		}
@@ -226,7 +412,7 @@ func (o *initOpts) installProviders(ctx context.Context) error {
 }
 
 func (o *initOpts) installPackages(ctx context.Context) error {
-	list, err := catalog.FilterBy(catalog.IsAPackage())
+	list, err := catalog.FilterBy(o.catalogOpts(), o.forCluster("package", catalog.IsAPackage()))
 	if err != nil {
 		return fmt.Errorf("fetching providers from catalog: %w", err)
 	}
@@ -237,16 +423,24 @@ func (o *initOpts) installPackages(ctx context.Context) error {
 		err := configurations.InstallFromRepo(ctx, configurations.InstallOpts{
 			RESTConfig: o.restConfig,
 			Info:       &el,
+			Catalog:    o.catalogOpts(),
 			Namespace:  o.namespace,
 			EventBus:   o.bus,
 			Verbose:    o.verbose,
+			PackageFn:  o.packageFn(),
+
+			ImageRegistries: o.mirrors,
+			PullSecrets:     o.pullSecrets,
+			Timeout:         waitTimeouts.For(wait.PhaseConfigurations),
+			DiagnosticsDir:  o.diagnosticsDir,
 		})
 
 		if err != nil {
 			return fmt.Errorf("installing package '%s': %w", el.Name, err)
 		}
 
-		o.bus.Publish(events.NewDoneEvent("Package %s (%s) installed", el.Name, el.Version))
+		o.bus.Publish(events.NewDoneEvent("Package %s (%s) installed from %s catalog", el.Name, el.Version, el.Source))
+		o.recordInstall(ctx, el)
 		if o.verbose {
 			o.bus.Publish(events.NewDebugEvent("> image: %s", el.Image))
 		}
@@ -276,6 +470,12 @@ func (o *initOpts) promptForClaims(ctx context.Context) ([]string, error) {
 	res = append(res, fmt.Sprintf("version=%s", "5.22.1"))
 
 	for _, el := range fields {
+		// Use proxy settings from flags
+		if val, ok := o.proxyFieldValue(el); ok {
+			res = append(res, val)
+			continue
+		}
+
 		if !el.Required {
 			if len(el.Default) != 0 {
 				val, err := defaultFieldValue(el)
@@ -292,15 +492,49 @@ func (o *initOpts) promptForClaims(ctx context.Context) ([]string, error) {
 			o.bus.Publish(events.NewDoneEvent("Setting catalogUrl to " + o.catalogUrl))
 			res = append(res, fmt.Sprintf("%s=%s", el.Name, o.catalogUrl))
 		} else {
-			res = append(res, promptForFieldValue(el))
+			// prompts of concurrent contexts are asked one at a time
+			promptMu.Lock()
+			res = append(res, promptForFieldValue(el, o.promptPrefix))
+			promptMu.Unlock()
 		}
 	}
 
 	return res, nil
 }
 
-func promptForFieldValue(el compositeresourcedefinitions.Field) (val string) {
+// proxyFieldValue returns the claim value of the httpProxy, httpsProxy
+// and noProxy fields (at any depth) when set with flags.
+func (o *initOpts) proxyFieldValue(el compositeresourcedefinitions.Field) (string, bool) {
+	name := el.Name
+	if idx := strings.LastIndex(name, "."); idx != -1 {
+		name = name[idx+1:]
+	}
+
+	val := ""
+	switch strings.ToLower(name) {
+	case "httpproxy":
+		val = httpClient.httpProxy
+	case "httpsproxy":
+		val = httpClient.httpsProxy
+	case "noproxy":
+		val = httpClient.noProxy
+	}
+
+	if len(val) == 0 {
+		return "", false
+	}
+
+	// commas separate the values parsed by strvals
+	return fmt.Sprintf("%s=%s", el.Name, strings.ReplaceAll(val, ",", `\,`)), true
+}
+
+var promptMu sync.Mutex
+
+func promptForFieldValue(el compositeresourcedefinitions.Field, prefix string) (val string) {
 	label := fmt.Sprintf(" > %s", el.Name)
+	if len(prefix) > 0 {
+		label = fmt.Sprintf(" [%s] > %s", prefix, el.Name)
+	}
 
 	switch el.Type {
 	case compositeresourcedefinitions.TypeBoolean:
@@ -395,8 +629,7 @@ func (o *initOpts) applyClaims(ctx context.Context, vals []string) error {
 
 	o.bus.Publish(events.NewDoneEvent("core package claims installed"))
 
-	o.bus.Publish(events.NewStartWaitEvent("waiting for readiness ..."))
-	err = claims.WaitUntilModuleIsReady(ctx, o.restConfig, coreModule)
+	err = waitForClaim(ctx, o.restConfig, coreModule, o.bus, o.verbose)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+)
+
+// InterruptedError is returned when a command is cancelled (i.e. Ctrl-C)
+// while running one of its steps.
+type InterruptedError struct {
+	// Step is the name of the interrupted step.
+	Step string
+	// Resume tells how to complete the command.
+	Resume string
+	Err    error
+}
+
+func (e *InterruptedError) Error() string {
+	msg := fmt.Sprintf("interrupted at step '%s'", e.Step)
+	if len(e.Resume) > 0 {
+		msg = fmt.Sprintf("%s, %s", msg, e.Resume)
+	}
+	return msg
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// step is a named phase of a command.
+type step struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// runSteps runs the steps in order publishing their start, completion or
+// failure. When ctx is cancelled the error is an *InterruptedError naming
+// the step and carrying the resume hint.
+func runSteps(ctx context.Context, bus eventbus.BusPublisher, resume string, steps ...step) error {
+	for _, el := range steps {
+		if err := ctx.Err(); err != nil {
+			err := &InterruptedError{Step: el.name, Resume: resume, Err: err}
+			bus.Publish(events.NewFailure(el.name, err))
+			return err
+		}
+
+		bus.Publish(events.NewStepStarted(el.name, nil))
+		start := time.Now()
+
+		err := el.fn(ctx)
+		if ctx.Err() != nil {
+			err = &InterruptedError{Step: el.name, Resume: resume, Err: ctx.Err()}
+		}
+		if err != nil {
+			bus.Publish(events.NewFailure(el.name, err))
+			return err
+		}
+
+		bus.Publish(events.NewStepCompleted(el.name, time.Since(start)))
+	}
+
+	return nil
+}
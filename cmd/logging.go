@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/osutils"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+const (
+	logFormatAuto = "auto"
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	// maxLogFiles is the number of run logs kept in the logs directory.
+	maxLogFiles = 20
+)
+
+// logOpts select the logger shared by all the commands.
+type logOpts struct {
+	format string
+}
+
+var logging = &logOpts{}
+
+func (o *logOpts) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.format, "log-format", logFormatAuto, "output format: text, json or auto (json when stdout is not a terminal)")
+}
+
+// complete configures the logger; the run log is skipped when it cannot be
+// created.
+func (o *logOpts) complete() error {
+	json := false
+	switch o.format {
+	case logFormatAuto:
+		json = !term.IsTerminal(int(os.Stdout.Fd()))
+	case logFormatText:
+	case logFormatJSON:
+		json = true
+	default:
+		return fmt.Errorf("invalid log format '%s' (%s, %s, %s)", o.format, logFormatAuto, logFormatText, logFormatJSON)
+	}
+
+	f, err := openRunLog()
+	if err != nil {
+		log.Configure(json, nil)
+		return nil
+	}
+
+	log.Configure(json, f)
+	return nil
+}
+
+// openRunLog creates the log file of this run in ~/.lash/logs, removing the
+// oldest ones.
+func openRunLog() (*os.File, error) {
+	dir, err := osutils.GetAppDir(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = filepath.Join(dir, "logs")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	pruneFiles(filepath.Join(dir, appName+"-*.log"), maxLogFiles-1)
+
+	name := fmt.Sprintf("%s-%s-%d.log", appName, time.Now().UTC().Format("20060102-150405"), os.Getpid())
+	return os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+}
+
+// pruneFiles keeps the newest files matching pattern; the names sort by
+// time.
+func pruneFiles(pattern string, keep int) {
+	all, err := filepath.Glob(pattern)
+	if err != nil || len(all) <= keep {
+		return
+	}
+
+	sort.Strings(all)
+	for _, el := range all[:len(all)-keep] {
+		os.Remove(el)
+	}
+}
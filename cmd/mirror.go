@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Machiel/slugify"
+	"github.com/platfornow/lash/internal/catalog"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/oci"
+	"github.com/spf13/cobra"
+)
+
+func newMirrorCmd() *cobra.Command {
+	o := mirrorOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "mirror [NAME...] --registry <REGISTRY>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Copy the catalog package images into a private registry",
+		Example: "  lash mirror --registry registry.corp/upbound\n" +
+			"  lash mirror provider-helm --registry localhost:5000 --dry-run",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.complete(); err != nil {
+				return err
+			}
+
+			return o.run(cmd.Context(), args)
+		},
+	}
+
+	cmd.Flags().StringVar(&o.registry, "registry", "", "destination registry, optionally with a repository prefix (i.e. registry.corp/upbound)")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "only print the images that would be copied")
+	cmd.Flags().BoolVar(&o.plainHTTP, "plain-http", false, "talk to the registries over plain HTTP")
+	cmd.Flags().StringVar(&o.catalogPublicKey, "catalog-public-key", "", "minisign public key used to verify the catalog index signature")
+	cmd.Flags().StringArrayVar(&o.catalogSources, "catalog-source", []string{}, "additional catalog index as name=location[,priority][,key=publickey] (location can be a URL or a local directory, publickey a minisign public key verifying the index signature)")
+	cmd.Flags().BoolVar(&o.insecureSkipVerify, "insecure-skip-verify", false, "do not verify catalog signature and manifest digests")
+	cmd.MarkFlagRequired("registry")
+
+	return cmd
+}
+
+type mirrorOpts struct {
+	registry           string
+	dryRun             bool
+	plainHTTP          bool
+	catalogPublicKey   string
+	catalogSources     []string
+	insecureSkipVerify bool
+	sources            []catalog.Source
+}
+
+func (o *mirrorOpts) complete() (err error) {
+	if len(strings.TrimSpace(o.registry)) == 0 {
+		return fmt.Errorf("destination registry is required")
+	}
+
+	o.sources, err = catalog.ParseSources(o.catalogSources)
+	return err
+}
+
+func (o *mirrorOpts) run(ctx context.Context, names []string) error {
+	all, err := catalog.FilterBy(catalog.FetchOpts{
+		PublicKey:          o.catalogPublicKey,
+		Sources:            o.sources,
+		InsecureSkipVerify: o.insecureSkipVerify,
+	}, catalog.All())
+	if err != nil {
+		return fmt.Errorf("fetching catalog: %w", err)
+	}
+
+	items, err := selectPackages(all, names)
+	if err != nil {
+		return err
+	}
+
+	cli := oci.NewClient()
+	cli.PlainHTTP = o.plainHTTP
+
+	l := log.GetInstance()
+
+	values := [][]string{}
+	for _, el := range items {
+		src, err := packageReference(el)
+		if err != nil {
+			return err
+		}
+		if src == nil {
+			l.Warnf("%s: no image in catalog, skipped", el.Name)
+			continue
+		}
+
+		dst, err := src.Mirror(o.registry)
+		if err != nil {
+			return err
+		}
+
+		if o.dryRun {
+			values = append(values, []string{el.Name, src.String(), dst.String(), "-"})
+			continue
+		}
+
+		l.StartWait(fmt.Sprintf("copying %s to %s...", src, dst))
+		dgst, err := cli.Copy(ctx, *src, dst)
+		l.StopWait()
+		if err != nil {
+			return fmt.Errorf("mirroring '%s': %w", el.Name, err)
+		}
+
+		l.Donef("%s mirrored to %s", el.Name, dst)
+		values = append(values, []string{el.Name, src.String(), dst.String(), dgst})
+	}
+
+	log.PrintTable(l, []string{"NAME", "SOURCE", "DESTINATION", "DIGEST"}, values)
+
+	return nil
+}
+
+// selectPackages returns the catalog entries with the given names, or
+// all the entries when no name is given.
+func selectPackages(all *catalog.Catalog, names []string) ([]catalog.PackageInfo, error) {
+	if len(names) == 0 {
+		return all.Items, nil
+	}
+
+	res := make([]catalog.PackageInfo, 0, len(names))
+	for _, name := range names {
+		el := all.Find(slugify.Slugify(name))
+		if el == nil {
+			return nil, fmt.Errorf("package '%s' not found in catalog", name)
+		}
+		res = append(res, *el)
+	}
+
+	return res, nil
+}
+
+// packageReference returns the image of the catalog entry, tagged with
+// the entry version when the image has no tag; nil if there is no image.
+func packageReference(el catalog.PackageInfo) (*oci.Reference, error) {
+	if len(el.Image) == 0 {
+		return nil, nil
+	}
+
+	img := el.Image
+	if _, tag := catalog.SplitImage(img); len(tag) == 0 && len(el.Version) > 0 {
+		img = fmt.Sprintf("%s:%s", img, el.Version)
+	}
+
+	ref, err := oci.ParseReference(img)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image of '%s': %w", el.Name, err)
+	}
+
+	return &ref, nil
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	xpextv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/platfornow/lash/internal/claims"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane/compositeresourcedefinitions"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newModuleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "module <COMMAND>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Manage the modules defined by the installed composite resource definitions",
+	}
+
+	cmd.AddCommand(newModuleListCmd())
+	cmd.AddCommand(newModuleEnableCmd())
+	cmd.AddCommand(newModuleDisableCmd())
+	cmd.AddCommand(newModuleDescribeCmd())
+
+	return cmd
+}
+
+type moduleOpts struct {
+	kubeconfig        string
+	kubeconfigContext string
+	namespace         string
+	claimName         string
+}
+
+func (o *moduleOpts) addFlags(fs *pflag.FlagSet) {
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	fs.StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	fs.StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context to use")
+}
+
+// addClaimFlags adds the flags selecting the module claim.
+func (o *moduleOpts) addClaimFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.namespace, "namespace", "n", "landscape-system", "namespace of the claim (ignored for cluster scoped modules)")
+	fs.StringVar(&o.claimName, "claim-name", "", "name of the claim (default the lowercase module kind)")
+}
+
+func (o *moduleOpts) restConfig() (*rest.Config, error) {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+}
+
+// find returns the composite resource definition of the named module.
+func (o *moduleOpts) find(ctx context.Context, rc *rest.Config, name string) (*xpextv1.CompositeResourceDefinition, error) {
+	xrd, err := compositeresourcedefinitions.Find(ctx, rc, name)
+	if err != nil {
+		return nil, err
+	}
+	if xrd == nil {
+		return nil, fmt.Errorf("module '%s' not found (see 'lash module list')", name)
+	}
+
+	return xrd, nil
+}
+
+// module returns the managed resource of the module claim.
+func (o *moduleOpts) module(xrd *xpextv1.CompositeResourceDefinition) (*claims.Module, error) {
+	name := o.claimName
+	if len(name) == 0 {
+		name = strings.ToLower(moduleKind(xrd))
+	}
+
+	return claims.NewModule(xrd, name, o.namespace)
+}
+
+// moduleKind returns the claim kind or, without claims, the composite kind.
+func moduleKind(xrd *xpextv1.CompositeResourceDefinition) string {
+	if xrd.OffersClaim() {
+		return xrd.Spec.ClaimNames.Kind
+	}
+	return xrd.Spec.Names.Kind
+}
+
+// readyCount returns the number of ready objects.
+func readyCount(all []unstructured.Unstructured) int {
+	n := 0
+	for i := range all {
+		if conditionIsTrue(&all[i], "Ready") {
+			n++
+		}
+	}
+	return n
+}
+
+func newModuleListCmd() *cobra.Command {
+	o := moduleOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "list",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "List the available modules and their instances",
+		Example:               "  lash module list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			all, err := compositeresourcedefinitions.ListTyped(ctx, rc)
+			if err != nil {
+				return err
+			}
+
+			values := [][]string{}
+			for i := range all {
+				mod, err := o.module(&all[i])
+				if err != nil {
+					values = append(values, []string{all[i].GetName(), moduleKind(&all[i]), "-", "-"})
+					continue
+				}
+
+				items, err := claims.List(ctx, rc, mod)
+				if err != nil {
+					return err
+				}
+
+				values = append(values, []string{
+					all[i].GetName(),
+					moduleKind(&all[i]),
+					mod.GetGroupVersionKind().Version,
+					fmt.Sprintf("%d/%d", readyCount(items), len(items)),
+				})
+			}
+
+			log.PrintTable(log.GetInstance(), []string{"NAME", "KIND", "VERSION", "READY"}, values)
+
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newModuleEnableCmd() *cobra.Command {
+	o := moduleOpts{}
+	var values []string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:                   "enable <NAME>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Create the module claim and wait until it is ready",
+		Example: "  lash module enable observability\n" +
+			"  lash module enable xobservabilities.pkg.platformnow.io --set retention=7d",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			xrd, err := o.find(ctx, rc, args[0])
+			if err != nil {
+				return err
+			}
+
+			mod, err := o.module(xrd)
+			if err != nil {
+				return err
+			}
+
+			fields, err := compositeresourcedefinitions.GetSpecFields(xrd)
+			if err != nil {
+				return err
+			}
+
+			inp, err := claimSpec(fields, values)
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.StartWait(fmt.Sprintf("installing %s module claim...", args[0]))
+			err = claims.ApplyModule(ctx, claims.ModuleOpts{
+				RESTConfig: rc,
+				Data:       inp,
+			}, mod)
+			if err != nil {
+				l.StopWait()
+				return err
+			}
+			l.Donef("%s %s created", mod.GetGroupVersionKind().Kind, mod.Name())
+
+			err = waitForClaim(ctx, rc, mod, newLogBus(l), verbose)
+			l.StopWait()
+			if err != nil {
+				return err
+			}
+			l.Donef("%s module is ready", args[0])
+
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	o.addClaimFlags(cmd.Flags())
+	cmd.Flags().StringSliceVar(&values, "set", []string{}, "claim spec values, not prompted")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the composed resources while waiting")
+
+	return cmd
+}
+
+func newModuleDisableCmd() *cobra.Command {
+	o := moduleOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "disable <NAME>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Remove the module claim",
+		Example:               "  lash module disable observability",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			xrd, err := o.find(ctx, rc, args[0])
+			if err != nil {
+				return err
+			}
+
+			mod, err := o.module(xrd)
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.StartWait(fmt.Sprintf("removing %s module claim...", args[0]))
+			defer l.StopWait()
+
+			err = claims.DeleteModule(ctx, claims.DeleteOpts{
+				RESTConfig: rc,
+				Name:       mod.Name(),
+			}, mod)
+			if err != nil {
+				return err
+			}
+
+			l.Donef("%s %s removed", mod.GetGroupVersionKind().Kind, mod.Name())
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	o.addClaimFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newModuleDescribeCmd() *cobra.Command {
+	o := moduleOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "describe <NAME>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Show the module spec fields and instances",
+		Example:               "  lash module describe gitops",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			rc, err := o.restConfig()
+			if err != nil {
+				return err
+			}
+
+			xrd, err := o.find(ctx, rc, args[0])
+			if err != nil {
+				return err
+			}
+
+			mod, err := o.module(xrd)
+			if err != nil {
+				return err
+			}
+
+			fields, err := compositeresourcedefinitions.GetSpecFields(xrd)
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.Infof("Name:    %s", xrd.GetName())
+			l.Infof("Kind:    %s", mod.GetGroupVersionKind().Kind)
+			l.Infof("Version: %s", mod.GetGroupVersionKind().GroupVersion())
+			if xrd.OffersClaim() {
+				l.Info("Scope:   namespaced claim")
+			} else {
+				l.Info("Scope:   cluster")
+			}
+
+			values := [][]string{}
+			for _, el := range fields {
+				values = append(values, []string{
+					el.Name,
+					el.Type,
+					fmt.Sprintf("%t", el.Required),
+					valueOrNone(el.Default),
+					strings.ReplaceAll(el.Description, "\n", " "),
+				})
+			}
+			log.PrintTable(l, []string{"FIELD", "TYPE", "REQUIRED", "DEFAULT", "DESCRIPTION"}, values)
+
+			items, err := claims.List(ctx, rc, mod)
+			if err != nil {
+				return err
+			}
+
+			values = [][]string{}
+			for _, el := range items {
+				values = append(values, []string{
+					el.GetName(),
+					valueOrNone(el.GetNamespace()),
+					fmt.Sprintf("%t", conditionIsTrue(&el, "Synced")),
+					fmt.Sprintf("%t", conditionIsTrue(&el, "Ready")),
+				})
+			}
+			log.PrintTable(l, []string{"NAME", "NAMESPACE", "SYNCED", "READY"}, values)
+
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+
+	return cmd
+}
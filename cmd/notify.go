@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/platfornow/lash/internal/config"
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/notify"
+)
+
+// notifyBuffer is the number of step events queued for a slow webhook.
+const notifyBuffer = 100
+
+// newNotifiers returns the notifiers of the webhooks of the config file;
+// their posts are cut short once ctx is done.
+func newNotifiers(ctx context.Context, webhooks []config.Webhook, title string) ([]*notify.Notifier, error) {
+	res := []*notify.Notifier{}
+	for _, el := range webhooks {
+		n, err := notify.New(notify.Opts{
+			URL:     el.URL,
+			Format:  el.Format,
+			Headers: el.Headers,
+			Retries: el.Retries,
+			Title:   title,
+			Context: ctx,
+		})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+
+	return res, nil
+}
+
+// notifyRun runs fn posting the step events of the bus to the notifiers and,
+// when fn returns, the summary. The bus is closed once fn returns; failed
+// posts are warnings.
+func notifyRun(bus eventbus.Bus, notifiers []*notify.Notifier, l log.Logger, fn func() error) error {
+	if len(notifiers) == 0 {
+		return fn()
+	}
+
+	for _, el := range notifiers {
+		bus.SubscribeAsync("step:*", el.Handle, notifyBuffer)
+	}
+
+	err := fn()
+
+	// wait for the queued posts
+	bus.Close()
+	for _, el := range notifiers {
+		if perr := el.Summary(err); perr != nil {
+			l.Warnf("webhook notification: %s", perr.Error())
+		}
+	}
+
+	return err
+}
+
+// runTitle names a run of the command in the notifications.
+func runTitle(command, kubeContext string) string {
+	return strings.TrimSpace(appName + " " + command + " " + kubeContext)
+}
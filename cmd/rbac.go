@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/rbac"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newRBACCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "rbac <COMMAND>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Short:                 "Inspect the permissions granted by lash",
+	}
+
+	cmd.AddCommand(newRBACAuditCmd())
+
+	return cmd
+}
+
+func newRBACAuditCmd() *cobra.Command {
+	var kubeconfig, kubeconfigContext, namespace string
+
+	cmd := &cobra.Command{
+		Use:                   "audit",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "List the cluster role bindings created by lash and flag the cluster-admin ones",
+		Example:               "  lash rbac audit",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			yml, err := os.ReadFile(kubeconfig)
+			if err != nil {
+				return err
+			}
+
+			rc, err := core.RESTConfigFromBytes(yml, kubeconfigContext)
+			if err != nil {
+				return err
+			}
+
+			all, err := rbac.Audit(cmd.Context(), rc, namespace)
+			if err != nil {
+				return err
+			}
+
+			admins := 0
+			values := [][]string{}
+			for _, el := range all {
+				flag := "-"
+				if el.ClusterAdmin {
+					flag = "yes"
+					admins++
+				}
+
+				values = append(values, []string{
+					el.Binding,
+					el.Role,
+					flag,
+					strings.Join(el.Subjects, ","),
+				})
+			}
+
+			l := log.GetInstance()
+			log.PrintTable(l, []string{"BINDING", "ROLE", "CLUSTER-ADMIN", "SUBJECTS"}, values)
+
+			if admins > 0 {
+				l.Warnf("%d of %d bindings grant cluster-admin (reinstall with --rbac-mode=%s)", admins, len(all), rbac.ModeMinimal)
+			}
+
+			return nil
+		},
+	}
+
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	cmd.Flags().StringVar(&kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	cmd.Flags().StringVar(&kubeconfigContext, "context", "", "kubeconfig context to use")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "landscape-system", "namespace where landscape idp is installed")
+
+	return cmd
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/osutils"
+	"github.com/spf13/pflag"
+)
+
+// maxRecordings is the number of recordings kept in the recordings directory.
+const maxRecordings = 20
+
+// recordOpts select where init and uninstall record the published events.
+type recordOpts struct {
+	path     string
+	disabled bool
+}
+
+func (o *recordOpts) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.path, "record", "", "file where to record the events as JSON lines for 'lash replay' (default ~/.lash/recordings/lash-<TIMESTAMP>-<COMMAND>.jsonl)")
+	fs.BoolVar(&o.disabled, "no-record", false, "do not record the events")
+}
+
+// start records the events published on the bus until the returned func is
+// called, which warns about the events that could not be recorded; suffix
+// tells apart the recordings of the contexts when fanning out. The default
+// recording is skipped when it cannot be created.
+func (o *recordOpts) start(bus eventbus.BusSubscriber, l log.Logger, command, suffix string) (func(), error) {
+	if o.disabled {
+		return func() {}, nil
+	}
+
+	f, err := o.create(command, suffix)
+	if err != nil {
+		if len(o.path) == 0 {
+			return func() {}, nil
+		}
+		return nil, err
+	}
+
+	rec := events.NewRecorder(f)
+	handle := rec.Handle
+	if len(o.path) == 0 {
+		// the debug events may hold the prompted values (i.e. the claim
+		// spec), they are only recorded to an explicit --record file
+		record := handle
+		handle = func(e eventbus.Event) {
+			if e.EventID() != events.DebugEventID {
+				record(e)
+			}
+		}
+	}
+
+	sub := bus.Subscribe(eventbus.Wildcard, handle)
+
+	return func() {
+		bus.Unsubscribe(sub)
+		err := rec.Err()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			l.Warnf("the recording %s is incomplete: %s", f.Name(), err.Error())
+		}
+	}, nil
+}
+
+// create creates the recording file, in ~/.lash/recordings removing the
+// oldest ones unless a path is set.
+func (o *recordOpts) create(command, suffix string) (*os.File, error) {
+	name := o.path
+	if len(name) == 0 {
+		dir, err := osutils.GetAppDir(appName)
+		if err != nil {
+			return nil, err
+		}
+
+		dir = filepath.Join(dir, "recordings")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+
+		pruneFiles(filepath.Join(dir, appName+"-*.jsonl"), maxRecordings-1)
+
+		name = filepath.Join(dir, fmt.Sprintf("%s-%s-%s.jsonl", appName, time.Now().UTC().Format("20060102-150405"), command))
+	}
+
+	if len(suffix) > 0 {
+		ext := filepath.Ext(name)
+		name = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), safeFileName(suffix), ext)
+	}
+
+	return os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+}
+
+// safeFileName replaces the characters that are not letters, digits, '.',
+// '_' or '-' (i.e. the '/' and ':' of the EKS context names) with '_'.
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordingSuffix(t *testing.T) {
+	dir := t.TempDir()
+	o := &recordOpts{path: filepath.Join(dir, "init.jsonl")}
+
+	f, err := o.create("init", "arn:aws:eks:us-east-1:123:cluster/prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	want := filepath.Join(dir, "init-arn_aws_eks_us-east-1_123_cluster_prod.jsonl")
+	if f.Name() != want {
+		t.Fatalf("expected %s, got %s", want, f.Name())
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+)
+
+func newReplayCmd() *cobra.Command {
+	var speed float64
+
+	cmd := &cobra.Command{
+		Use:                   "replay <FILE>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Replay the events recorded by init or uninstall",
+		Example: "  lash replay ~/.lash/recordings/lash-20261019-100000-init.jsonl\n" +
+			"  lash replay --speed 0 recording.jsonl",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if speed < 0 {
+				return fmt.Errorf("invalid speed %v, expected 0 or more", speed)
+			}
+
+			l := log.GetInstance()
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			bus := eventbus.New()
+			events.Subscribe(bus, events.LogHandler(l))
+
+			err = events.Replay(cmd.Context(), f, bus, speed)
+			l.StopWait()
+			return err
+		},
+	}
+
+	cmd.Flags().Float64Var(&speed, "speed", 1, "replay speed factor, 0 replays without waiting")
+
+	return cmd
+}
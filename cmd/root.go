@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/platfornow/lash/internal/httputils"
 	"github.com/platfornow/lash/internal/osutils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,14 +39,43 @@ func LandscapeShell(ver, build string) *cobra.Command {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return initializeConfig(cmd)
+			if err := initializeConfig(cmd); err != nil {
+				return err
+			}
+			if err := logging.complete(); err != nil {
+				return err
+			}
+			if err := waitTimeouts.complete(); err != nil {
+				return err
+			}
+			if err := httpClient.complete(); err != nil {
+				return err
+			}
+			// the config is loaded by the first download
+			httputils.SetCacheLoader(defaultCache)
+			return nil
 		},
 	}
 
+	waitTimeouts.addFlags(cmd.PersistentFlags())
+	logging.addFlags(cmd.PersistentFlags())
+	httpClient.addFlags(cmd.PersistentFlags())
+
 	cmd.AddCommand(newCmdVersion(ver, build))
 	cmd.AddCommand(newCreateCmd())
 	cmd.AddCommand(newInitCmd())
 	cmd.AddCommand(newUninstallCmd())
+	cmd.AddCommand(newCacheCmd())
+	cmd.AddCommand(newCatalogCmd())
+	cmd.AddCommand(newMirrorCmd())
+	cmd.AddCommand(newRBACCmd())
+	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newClusterCmd())
+	cmd.AddCommand(newGitopsCmd())
+	cmd.AddCommand(newModuleCmd())
+	cmd.AddCommand(newTraceCmd())
+	cmd.AddCommand(newSupportBundleCmd())
+	cmd.AddCommand(newReplayCmd())
 
 	return cmd
 }
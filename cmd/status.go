@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/platfornow/lash/internal/catalog"
+	"github.com/platfornow/lash/internal/claims"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane"
+	"github.com/platfornow/lash/internal/crossplane/configurations"
+	"github.com/platfornow/lash/internal/crossplane/providers"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newStatusCmd() *cobra.Command {
+	o := statusOpts{}
+
+	cmd := &cobra.Command{
+		Use:                   "status",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "Show the status of Crossplane, packages and Landscape claims",
+		Example: "  lash status\n" +
+			"  lash status --all-contexts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.fleet.enabled() {
+				return o.runAll(cmd.Context())
+			}
+
+			rows, err := o.collect(cmd.Context(), o.kubeconfigContext)
+			log.PrintTable(log.GetInstance(), []string{"KIND", "NAME", "VERSION", "READY"}, rows)
+
+			return err
+		},
+	}
+
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	cmd.Flags().StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	cmd.Flags().StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context to use")
+	o.fleet.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+type statusOpts struct {
+	kubeconfig        string
+	kubeconfigContext string
+	fleet             contextsOpts
+}
+
+// runAll prints a single table with the status of all the contexts.
+func (o *statusOpts) runAll(ctx context.Context) error {
+	mu := sync.Mutex{}
+	all := map[string][][]string{}
+
+	res, err := o.fleet.run(ctx, o.kubeconfig, func(ctx context.Context, kubeContext string, l log.Logger) error {
+		rows, err := o.collect(ctx, kubeContext)
+
+		mu.Lock()
+		all[kubeContext] = rows
+		mu.Unlock()
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	values := [][]string{}
+	for _, el := range res {
+		for _, row := range all[el.Context] {
+			values = append(values, append([]string{el.Context}, row...))
+		}
+	}
+	log.PrintTable(log.GetInstance(), []string{"CONTEXT", "KIND", "NAME", "VERSION", "READY"}, values)
+
+	return summarize(res)
+}
+
+// collect returns the status rows of the cluster; the error reports the
+// components not ready.
+func (o *statusOpts) collect(ctx context.Context, kubeContext string) ([][]string, error) {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := core.RESTConfigFromBytes(yml, kubeContext)
+	if err != nil {
+		return nil, err
+	}
+
+	ver, err := crossplane.InstalledVersion(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	notReady := 0
+	ready := func(ok bool) string {
+		if ok {
+			return "True"
+		}
+		notReady++
+		return "False"
+	}
+
+	rows := [][]string{
+		{"Crossplane", "crossplane", valueOrNone(ver), ready(len(ver) > 0)},
+	}
+
+	pkgs, err := packagesStatus(ctx, rc)
+	if err != nil {
+		return rows, err
+	}
+	for _, el := range pkgs {
+		_, tag := catalog.SplitImage(el.pkg)
+		rows = append(rows, []string{el.kind, el.name, valueOrNone(tag), ready(el.ready)})
+	}
+
+	all, err := claims.List(ctx, rc, claims.NewCore("core"))
+	if err != nil {
+		return rows, err
+	}
+	for _, el := range all {
+		rows = append(rows, []string{el.GetKind(), el.GetName(), "-", ready(conditionIsTrue(&el, "Ready"))})
+	}
+
+	if notReady > 0 {
+		return rows, fmt.Errorf("%d components not ready", notReady)
+	}
+
+	return rows, nil
+}
+
+type packageStatus struct {
+	kind  string
+	name  string
+	pkg   string
+	ready bool
+}
+
+// packagesStatus returns the installed providers and configurations,
+// ready when both installed and healthy.
+func packagesStatus(ctx context.Context, rc *rest.Config) ([]packageStatus, error) {
+	provs, err := providers.List(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs, err := configurations.List(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []packageStatus{}
+	for _, el := range append(provs, cfgs...) {
+		pkg, _, _ := unstructured.NestedString(el.Object, "spec", "package")
+		res = append(res, packageStatus{
+			kind:  el.GetKind(),
+			name:  el.GetName(),
+			pkg:   pkg,
+			ready: conditionIsTrue(&el, "Installed") && conditionIsTrue(&el, "Healthy"),
+		})
+	}
+
+	return res, nil
+}
+
+// conditionIsTrue returns true if the object status condition is True.
+func conditionIsTrue(obj *unstructured.Unstructured, condType string) bool {
+	all, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, el := range all {
+		cond, ok := el.(map[string]interface{})
+		if ok && cond["type"] == condType {
+			return cond["status"] == "True"
+		}
+	}
+	return false
+}
+
+func valueOrNone(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/platfornow/lash/internal/bundle"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newSupportBundleCmd() *cobra.Command {
+	var kubeconfig, kubeconfigContext, namespace, output string
+	var tailLines int64
+
+	cmd := &cobra.Command{
+		Use:                   "support-bundle",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Short:                 "Collect logs, objects and events of the installation in a tar.gz",
+		Example: "  lash support-bundle\n" +
+			"  lash support-bundle -o bundle.tar.gz --tail 200",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			yml, err := os.ReadFile(kubeconfig)
+			if err != nil {
+				return err
+			}
+
+			rc, err := core.RESTConfigFromBytes(yml, kubeconfigContext)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if len(output) == 0 {
+				output = bundle.Name(now) + ".tar.gz"
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+
+			l := log.GetInstance()
+			l.StartWait("collecting the support bundle...")
+			err = bundle.Write(cmd.Context(), f, bundle.Opts{
+				RESTConfig: rc,
+				Namespace:  namespace,
+				TailLines:  tailLines,
+				Now:        now,
+			})
+			l.StopWait()
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(output)
+				return err
+			}
+
+			l.Donef("support bundle written to %s (Secret values are redacted)", output)
+			return nil
+		},
+	}
+
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	cmd.Flags().StringVar(&kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	cmd.Flags().StringVar(&kubeconfigContext, "context", "", "kubeconfig context to use")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "landscape-system", "namespace where landscape is installed")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "archive file (default lash-support-bundle-<TIMESTAMP>.tar.gz)")
+	cmd.Flags().Int64Var(&tailLines, "tail", bundle.DefaultTailLines, "number of log lines collected per container")
+
+	return cmd
+}
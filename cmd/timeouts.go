@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/wait"
+	"github.com/spf13/pflag"
+)
+
+// timeoutOpts are the readiness wait timeouts shared by all the commands.
+type timeoutOpts struct {
+	global time.Duration
+	phases map[string]string
+
+	timeouts wait.Timeouts
+}
+
+var waitTimeouts = &timeoutOpts{}
+
+func (o *timeoutOpts) addFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&o.global, "timeout", 0, "how long to wait for the readiness of each phase (default per phase: crossplane 5m, providers 3m, configurations 2m, claims 5m)")
+	fs.StringToStringVar(&o.phases, "phase-timeout", map[string]string{}, fmt.Sprintf("timeout of a single phase as phase=duration, overriding --timeout (phases: %s)", strings.Join(wait.Phases(), ", ")))
+}
+
+func (o *timeoutOpts) complete() (err error) {
+	o.timeouts, err = wait.ParseTimeouts(o.global, o.phases)
+	return err
+}
+
+// For returns the timeout of the phase.
+func (o *timeoutOpts) For(phase string) time.Duration {
+	return o.timeouts.For(phase)
+}
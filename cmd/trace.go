@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/log"
+	"github.com/platfornow/lash/internal/trace"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func newTraceCmd() *cobra.Command {
+	var kubeconfig, kubeconfigContext, namespace, output string
+
+	cmd := &cobra.Command{
+		Use:                   "trace <KIND>/<NAME>",
+		DisableSuggestions:    true,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Short:                 "Show the tree of the objects behind a Crossplane claim or composite",
+		Example: "  lash trace core/core\n" +
+			"  lash trace gitops.pkg.platformnow.io/gitops -o json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			idx := strings.LastIndex(args[0], "/")
+			if idx <= 0 || idx == len(args[0])-1 {
+				return fmt.Errorf("invalid argument '%s', expected <KIND>/<NAME>", args[0])
+			}
+
+			if output != "text" && output != "json" {
+				return fmt.Errorf("invalid output format '%s' (text, json)", output)
+			}
+
+			yml, err := os.ReadFile(kubeconfig)
+			if err != nil {
+				return err
+			}
+
+			rc, err := core.RESTConfigFromBytes(yml, kubeconfigContext)
+			if err != nil {
+				return err
+			}
+
+			tree, err := trace.Build(cmd.Context(), trace.Opts{
+				RESTConfig: rc,
+				Kind:       args[0][:idx],
+				Name:       args[0][idx+1:],
+				Namespace:  namespace,
+			})
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(tree)
+			}
+
+			return trace.Render(log.GetInstance(), tree, time.Now())
+		},
+	}
+
+	defaultKubeconfig := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
+	if len(defaultKubeconfig) == 0 {
+		defaultKubeconfig = clientcmd.RecommendedHomeFile
+	}
+
+	cmd.Flags().StringVar(&kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
+	cmd.Flags().StringVar(&kubeconfigContext, "context", "", "kubeconfig context to use")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace of the claim (not needed for composites)")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format: text or json")
+
+	return cmd
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/platfornow/lash/internal/claims"
 	"github.com/platfornow/lash/internal/clusterrolebindings"
 	"github.com/platfornow/lash/internal/clusterroles"
+	"github.com/platfornow/lash/internal/config"
 	"github.com/platfornow/lash/internal/core"
 	"github.com/platfornow/lash/internal/crds"
 	"github.com/platfornow/lash/internal/crossplane"
@@ -55,25 +56,20 @@ func newUninstallCmd() *cobra.Command {
 				l.SetLevel(log.DebugLevel)
 			}
 
-			handler := events.LogHandler(l)
-			o.bus = eventbus.New()
-			eids := []eventbus.Subscription{
-				o.bus.Subscribe(events.StartWaitEventID, handler),
-				o.bus.Subscribe(events.StopWaitEventID, handler),
-				o.bus.Subscribe(events.DoneEventID, handler),
-				o.bus.Subscribe(events.DebugEventID, handler),
-			}
-			defer func() {
-				for _, e := range eids {
-					o.bus.Unsubscribe(e)
-				}
-			}()
-
 			if err := o.complete(); err != nil {
 				return err
 			}
 
-			return o.run()
+			if o.fleet.enabled() {
+				return o.fleet.fanOut(cmd.Context(), o.kubeconfig, func(ctx context.Context, kubeContext string, l log.Logger) error {
+					oc := o
+					oc.kubeconfigContext = kubeContext
+
+					return oc.runWithLogger(ctx, l)
+				})
+			}
+
+			return o.runWithLogger(cmd.Context(), l)
 		},
 	}
 
@@ -87,6 +83,8 @@ func newUninstallCmd() *cobra.Command {
 	cmd.Flags().StringVar(&o.kubeconfig, clientcmd.RecommendedConfigPathFlag, defaultKubeconfig, "absolute path to the kubeconfig file")
 	cmd.Flags().StringVar(&o.kubeconfigContext, "context", "", "kubeconfig context to use")
 	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", "landscape-system", "namespace where to install landscape idp")
+	o.fleet.addFlags(cmd.Flags())
+	o.recording.addFlags(cmd.Flags())
 
 	return cmd
 }
@@ -99,69 +97,100 @@ type uninstallOpts struct {
 	namespace         string
 	verbose           bool
 	dryRun            bool
+	fleet             contextsOpts
+	recording         recordOpts
+	webhooks          []config.Webhook
 }
 
-func (o *uninstallOpts) complete() (err error) {
+func (o *uninstallOpts) complete() error {
 	flag.Set("logtostderr", "false")
 	flag.Parse()
 	klog.InitFlags(nil)
 
-	yml, err := os.ReadFile(o.kubeconfig)
-	if err != nil {
-		return err
+	// dry runs are not notified
+	if o.dryRun {
+		return nil
 	}
 
-	o.restConfig, err = core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
 	}
+	o.webhooks = cfg.Webhooks
 
 	return nil
 }
 
-func (o *uninstallOpts) run() error {
-	ctx := context.TODO()
-
-	err := o.deleteArgoApplications(ctx)
+// runWithLogger connects to the cluster of the kubeconfig context and
+// uninstalls, rendering the events with the logger.
+func (o *uninstallOpts) runWithLogger(ctx context.Context, l log.Logger) error {
+	o.bus = eventbus.New()
 
-	err = o.deleteArgoProjects(ctx)
-
-	if err := o.deletePackages(ctx); err != nil {
-		return err
+	suffix := ""
+	if o.fleet.enabled() {
+		suffix = o.kubeconfigContext
 	}
-
-	if err := o.deleteProviders(ctx); err != nil {
+	stop, err := o.recording.start(o.bus, l, "uninstall", suffix)
+	if err != nil {
 		return err
 	}
+	defer stop()
 
-	if err := o.deleteReleases(ctx); err != nil {
-		return err
-	}
+	eids := events.Subscribe(o.bus, events.LogHandler(l))
+	defer func() {
+		for _, e := range eids {
+			o.bus.Unsubscribe(e)
+		}
+	}()
 
-	if err := o.deleteControllerConfigs(ctx); err != nil {
+	yml, err := os.ReadFile(o.kubeconfig)
+	if err != nil {
 		return err
 	}
 
-	if err := o.deleteXRDs(ctx); err != nil {
+	o.restConfig, err = core.RESTConfigFromBytes(yml, o.kubeconfigContext)
+	if err != nil {
 		return err
 	}
 
-	if err := o.deleteCrossplane(ctx); err != nil {
+	notifiers, err := newNotifiers(ctx, o.webhooks, runTitle("uninstall", o.kubeconfigContext))
+	if err != nil {
 		return err
 	}
 
-	o.bus.Publish(events.NewStartWaitEvent("Finishing cleaning..."))
-	o.deleteCompositions(ctx)
-	o.deleteCRDsQuietly(ctx)
-	o.deleteClusterRoleBindingsQuietly(ctx)
-	o.deleteClusterRolesQuietly(ctx)
-	o.deleteManagedResources(ctx)
-	o.deleteCrossplaneLock(ctx)
-	o.deleteProviderRevisions(ctx)
-	err = o.deleteNamespace(ctx)
-	o.bus.Publish(events.NewStartWaitEvent("Cleaning done"))
+	return notifyRun(o.bus, notifiers, l, func() error {
+		return o.run(ctx)
+	})
+}
 
-	return err
+func (o *uninstallOpts) run(ctx context.Context) error {
+	return runSteps(ctx, o.bus, "run 'lash uninstall' again to remove what is left",
+		step{"argo applications", func(ctx context.Context) error {
+			o.deleteArgoApplications(ctx)
+			o.deleteArgoProjects(ctx)
+			return nil
+		}},
+		step{"packages", o.deletePackages},
+		step{"providers", o.deleteProviders},
+		step{"releases", o.deleteReleases},
+		step{"controller configs", o.deleteControllerConfigs},
+		step{"composite resource definitions", o.deleteXRDs},
+		step{"crossplane", o.deleteCrossplane},
+		step{"cleanup", func(ctx context.Context) error {
+			o.bus.Publish(events.NewStartWaitEvent("Finishing cleaning..."))
+			o.deleteCompositions(ctx)
+			o.deleteCRDsQuietly(ctx)
+			o.deleteClusterRoleBindingsQuietly(ctx)
+			o.deleteClusterRolesQuietly(ctx)
+			o.deleteManagedResources(ctx)
+			o.deleteCrossplaneLock(ctx)
+			o.deleteProviderRevisions(ctx)
+			err := o.deleteNamespace(ctx)
+			o.bus.Publish(events.NewStartWaitEvent("Cleaning done"))
+
+			return err
+		}},
+	)
 }
 
 func (o *uninstallOpts) deleteArgoApplications(ctx context.Context) error {
@@ -199,7 +228,7 @@ func (o *uninstallOpts) deleteArgoApplications(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("application %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -241,7 +270,7 @@ func (o *uninstallOpts) deleteArgoProjects(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("project %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -283,7 +312,7 @@ func (o *uninstallOpts) deleteXRDs(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("provider config %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -344,7 +373,7 @@ func (o *uninstallOpts) deleteManagedResources(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("provider config %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -387,7 +416,7 @@ func (o *uninstallOpts) deleteCompositeResources(ctx context.Context, resource c
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("provider config %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -429,7 +458,7 @@ func (o *uninstallOpts) deleteReleases(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("release %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -513,7 +542,7 @@ func (o *uninstallOpts) deleteHelmProviderConfigs(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("provider config %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -555,7 +584,7 @@ func (o *uninstallOpts) deleteK8ProviderConfigs(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("provider config %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -597,7 +626,7 @@ func (o *uninstallOpts) deleteProviderRevisions(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("provider revision %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -633,7 +662,7 @@ func (o *uninstallOpts) deleteProviders(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("package %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -650,18 +679,19 @@ func (o *uninstallOpts) deleteControllerConfigs(ctx context.Context) error {
 	}
 
 	if o.dryRun {
-		o.bus.Publish(events.NewDebugEvent("found [%d] controller configs", len(all)))
+		o.bus.Publish(events.NewDebugEvent("found [%d] runtime configs", len(all)))
 	}
 
 	for _, el := range all {
 		if o.dryRun {
-			o.bus.Publish(events.NewDebugEvent(" > %s", el.GetName()))
+			o.bus.Publish(events.NewDebugEvent(" > %s (%s)", el.GetName(), el.GetKind()))
 			continue
 		}
 
 		err := controllerconfigs.Delete(ctx, controllerconfigs.DeleteOpts{
 			RESTConfig: o.restConfig,
 			Name:       el.GetName(),
+			GVK:        el.GroupVersionKind(),
 		})
 		if err != nil {
 			return err
@@ -698,7 +728,7 @@ func (o *uninstallOpts) deletePackages(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		o.bus.Publish(events.NewDoneEvent("package %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -765,7 +795,7 @@ func (o *uninstallOpts) deleteCrossplaneLock(ctx context.Context) error {
 			return err
 		}
 
-		o.bus.Publish(events.NewDoneEvent("lock %s uninstalled", el.GetName()))
+		o.bus.Publish(events.NewObjectApplied(&el, events.ActionDeleted))
 	}
 
 	return nil
@@ -818,7 +848,8 @@ func (o *uninstallOpts) deleteClusterRoleBindingsQuietly(ctx context.Context) {
 	}
 
 	res, err := core.Filter(all, func(obj unstructured.Unstructured) bool {
-		accept := (obj.GetName() == "provider-helm-admin-binding")
+		accept := (obj.GetLabels()[core.InstalledByLabel] == core.InstalledByValue)
+		accept = accept || (obj.GetName() == "provider-helm-admin-binding")
 		accept = accept || (obj.GetName() == "provider-kubernetes-admin-binding")
 		accept = accept || (obj.GetName() == "argocd-server-repo-server")
 		accept = accept || (obj.GetName() == "argocd-server-server")
@@ -853,7 +884,8 @@ func (o *uninstallOpts) deleteClusterRolesQuietly(ctx context.Context) {
 	}
 
 	res, err := core.Filter(all, func(obj unstructured.Unstructured) bool {
-		accept := (obj.GetName() == "argocd-server-aggregate-to-admin")
+		accept := (obj.GetLabels()[core.InstalledByLabel] == core.InstalledByValue)
+		accept = accept || (obj.GetName() == "argocd-server-aggregate-to-admin")
 		accept = accept || (obj.GetName() == "argocd-server-aggregate-to-edit")
 		accept = accept || (obj.GetName() == "argocd-server-aggregate-to-view")
 		accept = accept || (obj.GetName() == "argocd-server-application-controller")
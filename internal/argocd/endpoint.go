@@ -0,0 +1,109 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+const serverSelector = "app.kubernetes.io/name=argocd-server"
+
+// Endpoint locates the Argo CD server.
+type Endpoint struct {
+	// URL is the external address of the server; empty when the
+	// server is reachable only from inside the cluster.
+	URL       string
+	Service   string
+	Namespace string
+}
+
+// FindEndpoint looks for the Argo CD server Service and for the Ingress or
+// load balancer exposing it; it returns nil if Argo CD is not installed.
+func FindEndpoint(ctx context.Context, restConfig *rest.Config) (*Endpoint, error) {
+	svcs, err := core.List(ctx, core.ListOpts{
+		RESTConfig:    restConfig,
+		GVK:           schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+		LabelSelector: serverSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(svcs) == 0 {
+		return nil, nil
+	}
+
+	ingresses, err := core.List(ctx, core.ListOpts{
+		RESTConfig: restConfig,
+		GVK: schema.GroupVersionKind{
+			Group:   "networking.k8s.io",
+			Version: "v1",
+			Kind:    "Ingress",
+		},
+		Namespace: svcs[0].GetNamespace(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return endpointOf(&svcs[0], ingresses), nil
+}
+
+// endpointOf returns the endpoint of the service, preferring the host of an
+// Ingress routing to it over the load balancer address.
+func endpointOf(svc *unstructured.Unstructured, ingresses []unstructured.Unstructured) *Endpoint {
+	res := &Endpoint{
+		Service:   svc.GetName(),
+		Namespace: svc.GetNamespace(),
+	}
+
+	for _, ing := range ingresses {
+		tls, _, _ := unstructured.NestedSlice(ing.Object, "spec", "tls")
+		scheme := "http"
+		if len(tls) > 0 {
+			scheme = "https"
+		}
+
+		rules, _, _ := unstructured.NestedSlice(ing.Object, "spec", "rules")
+		for _, el := range rules {
+			rule, ok := el.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			host, _, _ := unstructured.NestedString(rule, "host")
+			paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+			for _, p := range paths {
+				path, ok := p.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _, _ := unstructured.NestedString(path, "backend", "service", "name")
+				if name == svc.GetName() && len(host) > 0 {
+					res.URL = fmt.Sprintf("%s://%s", scheme, host)
+					return res
+				}
+			}
+		}
+	}
+
+	lbs, _, _ := unstructured.NestedSlice(svc.Object, "status", "loadBalancer", "ingress")
+	for _, el := range lbs {
+		lb, ok := el.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addr, _, _ := unstructured.NestedString(lb, "hostname")
+		if len(addr) == 0 {
+			addr, _, _ = unstructured.NestedString(lb, "ip")
+		}
+		if len(addr) > 0 {
+			res.URL = fmt.Sprintf("https://%s", addr)
+			return res
+		}
+	}
+
+	return res
+}
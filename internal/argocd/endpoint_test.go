@@ -0,0 +1,66 @@
+package argocd
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestEndpointOf(t *testing.T) {
+	svc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "argocd-server", "namespace": "argocd"},
+		"status": map[string]interface{}{
+			"loadBalancer": map[string]interface{}{
+				"ingress": []interface{}{
+					map[string]interface{}{"ip": "10.0.0.7"},
+				},
+			},
+		},
+	}}
+
+	ing := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"tls": []interface{}{map[string]interface{}{"hosts": []interface{}{"argocd.example.com"}}},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"host": "argocd.example.com",
+					"http": map[string]interface{}{
+						"paths": []interface{}{
+							map[string]interface{}{
+								"backend": map[string]interface{}{
+									"service": map[string]interface{}{"name": "argocd-server"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		ingresses []unstructured.Unstructured
+		want      string
+	}{
+		{"ingress", []unstructured.Unstructured{ing}, "https://argocd.example.com"},
+		{"load balancer", nil, "https://10.0.0.7"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := endpointOf(svc, tc.ingresses)
+			if got.URL != tc.want {
+				t.Fatalf("expected %s, got: %s", tc.want, got.URL)
+			}
+			if got.Service != "argocd-server" || got.Namespace != "argocd" {
+				t.Fatalf("unexpected service: %s/%s", got.Namespace, got.Service)
+			}
+		})
+	}
+
+	unstructured.RemoveNestedField(svc.Object, "status")
+	if got := endpointOf(svc, nil); got.URL != "" {
+		t.Fatalf("expected no external URL, got: %s", got.URL)
+	}
+}
@@ -0,0 +1,50 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"path"
+	"time"
+)
+
+// archive writes the files of the bundle in a gzipped tarball under a
+// top level directory.
+type archive struct {
+	dir string
+	now time.Time
+	gz  *gzip.Writer
+	tw  *tar.Writer
+}
+
+func newArchive(w io.Writer, dir string, now time.Time) *archive {
+	gz := gzip.NewWriter(w)
+	return &archive{
+		dir: dir,
+		now: now,
+		gz:  gz,
+		tw:  tar.NewWriter(gz),
+	}
+}
+
+func (a *archive) add(name string, data []byte) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Name:    path.Join(a.dir, name),
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: a.now,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = a.tw.Write(data)
+	return err
+}
+
+func (a *archive) close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
@@ -0,0 +1,384 @@
+// Package bundle writes the support bundle: a tar.gz with what is needed to
+// troubleshoot a Landscape installation, with the Secrets redacted.
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane"
+	"github.com/platfornow/lash/internal/crossplane/compositeresourcedefinitions"
+	"github.com/platfornow/lash/internal/record"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultTailLines is the number of log lines collected per container.
+	DefaultTailLines = 1000
+
+	redacted = "REDACTED"
+)
+
+// packageKinds are collected whatever their labels.
+var packageKinds = []schema.GroupVersionKind{
+	{Group: "pkg.crossplane.io", Version: "v1", Kind: "Provider"},
+	{Group: "pkg.crossplane.io", Version: "v1", Kind: "ProviderRevision"},
+	{Group: "pkg.crossplane.io", Version: "v1", Kind: "Configuration"},
+	{Group: "pkg.crossplane.io", Version: "v1", Kind: "ConfigurationRevision"},
+	{Group: "apiextensions.crossplane.io", Version: "v1", Kind: "CompositeResourceDefinition"},
+	{Group: "apiextensions.crossplane.io", Version: "v1", Kind: "Composition"},
+}
+
+// labelledKinds are the kinds of the objects created by lash, collected
+// when they have the installed-by label.
+var labelledKinds = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "Namespace"},
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "ServiceAccount"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+	{Group: "pkg.crossplane.io", Version: "v1alpha1", Kind: "ControllerConfig"},
+	{Group: "pkg.crossplane.io", Version: "v1beta1", Kind: "DeploymentRuntimeConfig"},
+}
+
+type Opts struct {
+	RESTConfig *rest.Config
+	// Namespace where Crossplane and the providers are installed.
+	Namespace string
+	TailLines int64
+	// Now names the top level directory of the archive.
+	Now time.Time
+}
+
+// Name returns the archive top level directory for the time.
+func Name(now time.Time) string {
+	return "lash-support-bundle-" + now.UTC().Format("20060102-150405")
+}
+
+// Write collects the bundle and writes it to w as a tar.gz. What cannot be
+// collected is listed in errors.txt instead of failing the bundle.
+func Write(ctx context.Context, w io.Writer, opts Opts) error {
+	if opts.TailLines <= 0 {
+		opts.TailLines = DefaultTailLines
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	b := &bundler{
+		opts: opts,
+		arc:  newArchive(w, Name(opts.Now), opts.Now),
+		seen: map[string]bool{},
+	}
+
+	for _, fn := range []func(context.Context) error{
+		b.packages,
+		b.labelled,
+		b.claims,
+		b.events,
+		b.logs,
+		b.release,
+		b.record,
+	} {
+		if err := fn(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			b.errors = append(b.errors, err.Error())
+		}
+	}
+
+	if len(b.errors) > 0 {
+		if err := b.arc.add("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+
+	return b.arc.close()
+}
+
+type bundler struct {
+	opts   Opts
+	arc    *archive
+	seen   map[string]bool
+	errors []string
+}
+
+func (b *bundler) packages(ctx context.Context) error {
+	for _, gvk := range packageKinds {
+		if err := b.list(ctx, gvk, "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundler) labelled(ctx context.Context) error {
+	sel := fmt.Sprintf("%s=%s", core.InstalledByLabel, core.InstalledByValue)
+	for _, gvk := range labelledKinds {
+		if err := b.list(ctx, gvk, "", sel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claims collects the composites and the claims of all the installed
+// composite resource definitions.
+func (b *bundler) claims(ctx context.Context) error {
+	all, err := compositeresourcedefinitions.ListTyped(ctx, b.opts.RESTConfig)
+	if err != nil {
+		return fmt.Errorf("composite resource definitions: %w", err)
+	}
+
+	for _, xrd := range all {
+		gvks := []schema.GroupVersionKind{xrd.GetCompositeGroupVersionKind()}
+		if xrd.OffersClaim() {
+			gvks = append(gvks, xrd.GetClaimGroupVersionKind())
+		}
+
+		for _, gvk := range gvks {
+			if len(gvk.Version) == 0 {
+				continue
+			}
+			if err := b.list(ctx, gvk, "", ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *bundler) events(ctx context.Context) error {
+	all, err := core.List(ctx, core.ListOpts{
+		RESTConfig: b.opts.RESTConfig,
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "Event"},
+		Namespace:  b.opts.Namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("events: %w", err)
+	}
+
+	items := make([]interface{}, 0, len(all))
+	for _, el := range all {
+		items = append(items, sanitize(&el).Object)
+	}
+
+	dat, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.arc.add("events.yaml", dat)
+}
+
+// logs collects the logs of the pods in the namespace: Crossplane and the
+// providers; the previous logs too for restarted containers.
+func (b *bundler) logs(ctx context.Context) error {
+	all, err := core.List(ctx, core.ListOpts{
+		RESTConfig: b.opts.RESTConfig,
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Namespace:  b.opts.Namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("pods: %w", err)
+	}
+
+	cs, err := kubernetes.NewForConfig(b.opts.RESTConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, el := range all {
+		if err := b.add(&el); err != nil {
+			return err
+		}
+
+		pod := corev1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(el.UnstructuredContent(), &pod); err != nil {
+			return err
+		}
+
+		statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, st := range statuses {
+			files := map[string]bool{st.Name + ".log": false}
+			if st.RestartCount > 0 {
+				files[st.Name+".previous.log"] = true
+			}
+
+			for file, previous := range files {
+				dat, err := cs.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+					Container: st.Name,
+					TailLines: &b.opts.TailLines,
+					Previous:  previous,
+				}).DoRaw(ctx)
+				if err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					b.errors = append(b.errors, fmt.Sprintf("logs of %s/%s: %s", pod.Name, st.Name, err))
+					continue
+				}
+
+				if err := b.arc.add(path.Join("logs", pod.Name, file), dat); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *bundler) release(context.Context) error {
+	manifest, err := crossplane.ReleaseManifest(b.opts.RESTConfig, b.opts.Namespace)
+	if err != nil {
+		return fmt.Errorf("crossplane helm release: %w", err)
+	}
+	if len(manifest) == 0 {
+		return nil
+	}
+
+	manifest, err = redactManifest(manifest)
+	if err != nil {
+		return fmt.Errorf("crossplane helm release: %w", err)
+	}
+
+	return b.arc.add("helm/crossplane-manifest.yaml", []byte(manifest))
+}
+
+func (b *bundler) record(ctx context.Context) error {
+	rec, err := record.Load(ctx, b.opts.RESTConfig, b.opts.Namespace)
+	if err != nil {
+		return fmt.Errorf("install record: %w", err)
+	}
+
+	dat, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return b.arc.add("install-record.json", dat)
+}
+
+// list adds the objects of the kind, in all the namespaces.
+func (b *bundler) list(ctx context.Context, gvk schema.GroupVersionKind, namespace, selector string) error {
+	all, err := core.List(ctx, core.ListOpts{
+		RESTConfig:    b.opts.RESTConfig,
+		GVK:           gvk,
+		Namespace:     namespace,
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", gvk.Kind, err)
+	}
+
+	for i := range all {
+		if err := b.add(&all[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// add writes the sanitized object as resources/<kind>[/<namespace>]/<name>.yaml.
+func (b *bundler) add(obj *unstructured.Unstructured) error {
+	name := resourcePath(obj)
+	if b.seen[name] {
+		return nil
+	}
+	b.seen[name] = true
+
+	dat, err := yaml.Marshal(sanitize(obj).Object)
+	if err != nil {
+		return err
+	}
+
+	return b.arc.add(name, dat)
+}
+
+func resourcePath(obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	kind := strings.ToLower(gvk.Kind)
+	if len(gvk.Group) > 0 {
+		kind = kind + "." + gvk.Group
+	}
+
+	return path.Join("resources", kind, obj.GetNamespace(), obj.GetName()+".yaml")
+}
+
+// sanitize returns a copy of obj without the managed fields and, for
+// Secrets, with the values redacted.
+func sanitize(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	res := obj.DeepCopy()
+	res.SetManagedFields(nil)
+
+	if res.GroupVersionKind().Group != "" || res.GetKind() != "Secret" {
+		return res
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		values, ok, _ := unstructured.NestedMap(res.Object, field)
+		if !ok {
+			continue
+		}
+		for k := range values {
+			values[k] = redacted
+		}
+		//nolint:errcheck
+		unstructured.SetNestedMap(res.Object, values, field)
+	}
+
+	// the last applied configuration has the values too
+	if ann := res.GetAnnotations(); len(ann) > 0 {
+		if _, ok := ann[corev1.LastAppliedConfigAnnotation]; ok {
+			ann[corev1.LastAppliedConfigAnnotation] = redacted
+			res.SetAnnotations(ann)
+		}
+	}
+
+	return res
+}
+
+// redactManifest returns the multi document manifest with the Secrets
+// redacted; the other documents are left untouched.
+func redactManifest(manifest string) (string, error) {
+	docs := strings.Split(manifest, "\n---")
+	for i, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return "", err
+		}
+		if obj["kind"] != "Secret" {
+			continue
+		}
+
+		dat, err := yaml.Marshal(sanitize(&unstructured.Unstructured{Object: obj}).Object)
+		if err != nil {
+			return "", err
+		}
+		docs[i] = "\n" + string(dat)
+	}
+
+	return strings.Join(docs, "\n---"), nil
+}
@@ -0,0 +1,117 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSanitize(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":          "cluster-prod",
+			"namespace":     "argocd",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "lash"}},
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"token":"c2VjcmV0"}}`,
+			},
+		},
+		"data":       map[string]interface{}{"token": "c2VjcmV0"},
+		"stringData": map[string]interface{}{"password": "secret"},
+	}}
+
+	res := sanitize(obj)
+
+	token, _, _ := unstructured.NestedString(res.Object, "data", "token")
+	password, _, _ := unstructured.NestedString(res.Object, "stringData", "password")
+	if token != redacted || password != redacted {
+		t.Fatalf("expected redacted values, got: %v", res.Object)
+	}
+	if res.GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"] != redacted {
+		t.Fatal("expected the last applied configuration to be redacted")
+	}
+	if len(res.GetManagedFields()) != 0 {
+		t.Fatal("expected no managed fields")
+	}
+
+	// the original is untouched
+	if val, _, _ := unstructured.NestedString(obj.Object, "data", "token"); val != "c2VjcmV0" {
+		t.Fatal("expected the original object to be unchanged")
+	}
+}
+
+func TestRedactManifest(t *testing.T) {
+	manifest := `---
+# Source: crossplane/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: webhook-tls
+data:
+  tls.key: c2VjcmV0
+---
+# Source: crossplane/templates/service-account.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: crossplane`
+
+	res, err := redactManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(res, "c2VjcmV0") || !strings.Contains(res, "tls.key: "+redacted) {
+		t.Fatalf("expected the secret to be redacted:\n%s", res)
+	}
+	if !strings.Contains(res, "# Source: crossplane/templates/service-account.yaml\napiVersion: v1\nkind: ServiceAccount") {
+		t.Fatalf("expected the other documents untouched:\n%s", res)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	buf := bytes.Buffer{}
+	arc := newArchive(&buf, Name(now), now)
+	if err := arc.add("events.yaml", []byte("items: []\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := arc.add("logs/crossplane-abc/crossplane.log", []byte("started\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := arc.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	want := "lash-support-bundle-20261019-100000/events.yaml,lash-support-bundle-20261019-100000/logs/crossplane-abc/crossplane.log"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+}
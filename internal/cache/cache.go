@@ -0,0 +1,209 @@
+// Package cache provides an on-disk cache for downloaded catalog
+// indexes, manifests and Helm charts.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/platfornow/lash/internal/osutils"
+)
+
+const (
+	dirName    = "cache"
+	metaSuffix = ".json"
+	dataSuffix = ".data"
+)
+
+// Entry describes a cached download.
+type Entry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+	Size         int64     `json:"size"`
+}
+
+// Cache stores downloads in a directory, one metadata and one data
+// file per URL. It is safe for concurrent use.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	// mu keeps the metadata and data files of an entry consistent
+	mu sync.RWMutex
+}
+
+// New returns a cache rooted in dir; entries older than ttl must be
+// revalidated against the server.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// DefaultDir returns the cache directory inside the application dir.
+func DefaultDir(appName string) (string, error) {
+	dir, err := osutils.GetAppDir(appName)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, dirName), nil
+}
+
+// Dir returns the cache directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Fresh reports whether the entry can be used without revalidation.
+func (c *Cache) Fresh(e *Entry) bool {
+	return e != nil && time.Since(e.FetchedAt) < c.ttl
+}
+
+// Get returns the cached entry and data for url, or nil if not cached.
+func (c *Cache) Get(url string) (*Entry, []byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, err := c.readEntry(c.path(url, metaSuffix))
+	if err != nil || e == nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(c.path(url, dataSuffix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	return e, data, nil
+}
+
+// Put stores data for the entry URL.
+func (c *Cache) Put(e Entry, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	e.Size = int64(len(data))
+	if e.FetchedAt.IsZero() {
+		e.FetchedAt = time.Now()
+	}
+
+	if err := os.WriteFile(c.path(e.URL, dataSuffix), data, 0o644); err != nil {
+		return err
+	}
+
+	return c.writeEntry(e)
+}
+
+// Touch marks the entry for url as just revalidated.
+func (c *Cache) Touch(url string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.readEntry(c.path(url, metaSuffix))
+	if err != nil || e == nil {
+		return err
+	}
+
+	e.FetchedAt = time.Now()
+	return c.writeEntry(*e)
+}
+
+// List returns all cached entries sorted by URL.
+func (c *Cache) List() ([]Entry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	all, err := filepath.Glob(filepath.Join(c.dir, "*"+metaSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Entry{}
+	for _, el := range all {
+		e, err := c.readEntry(el)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			res = append(res, *e)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].URL < res[j].URL
+	})
+
+	return res, nil
+}
+
+// Clear removes all cached entries.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	all, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, el := range all {
+		name := el.Name()
+		if !strings.HasSuffix(name, metaSuffix) && !strings.HasSuffix(name, dataSuffix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache) path(url, suffix string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+suffix)
+}
+
+func (c *Cache) readEntry(fn string) (*Entry, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	e := &Entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, errors.New("corrupted cache entry: " + filepath.Base(fn))
+	}
+
+	return e, nil
+}
+
+func (c *Cache) writeEntry(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path(e.URL, metaSuffix), data, 0o644)
+}
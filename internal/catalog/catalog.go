@@ -1,19 +1,26 @@
 package catalog
 
 import (
+	"bytes"
 	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"fmt"
+	"os"
+	"path"
 	"strings"
-	"time"
 
 	"github.com/Machiel/slugify"
+	"github.com/platfornow/lash/internal/httputils"
+	rbacv1 "k8s.io/api/rbac/v1"
 )
 
 const (
 	catalogURL = "https://raw.githubusercontent.com/platformnow/catalog/master/index.json"
 )
 
+// ManagementPackages make up the management plane along with the entries
+// flagged as management: the providers configured by 'lash cluster add'.
+var ManagementPackages = []string{"provider-kubernetes", "provider-helm"}
+
 type Catalog struct {
 	Items []PackageInfo `json:"packages"`
 }
@@ -24,56 +31,128 @@ type PackageInfo struct {
 	Description string `json:"description"`
 	Version     string `json:"version"`
 	Cli         bool   `json:"cli"`
-	Manifest    string `json:"package"`
+	// Management entries make up the management plane and are installed
+	// only by init --management-cluster.
+	Management bool              `json:"management,omitempty"`
+	Manifest   string            `json:"package"`
+	Digests    map[string]string `json:"digests,omitempty"` // file name -> sha256:<hex>
+	Source     string            `json:"source,omitempty"`  // name of the catalog source
+	// RBAC is the least-privilege profile of a provider: the rules of the
+	// ClusterRole bound to its service account with --rbac-mode=minimal.
+	RBAC []rbacv1.PolicyRule `json:"rbac,omitempty"`
+
+	// signed is true when the index signature was checked: the digests
+	// of the entry are then required.
+	signed bool
+}
+
+// FetchOpts controls where the catalog is downloaded from and how
+// the downloaded index and manifests are verified.
+type FetchOpts struct {
+	// URL of the public catalog index, defaults to the PortalNOW catalog.
+	URL string
+	// PublicKey is the minisign public key used to verify the public
+	// index detached signature (<URL>.sig); no signature check when empty.
+	PublicKey string
+	// Sources are additional catalogs merged with the public one.
+	Sources []Source
+	// InsecureSkipVerify disables both signature and digest checks.
+	InsecureSkipVerify bool
+}
+
+// Fetch downloads all the catalog sources and merges their items.
+func Fetch(opts FetchOpts) (*Catalog, error) {
+	sources := opts.sources()
+
+	all := []*Catalog{}
+	for _, src := range sources {
+		cat, err := fetchSource(src, opts.InsecureSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("fetching catalog '%s': %w", src.Name, err)
+		}
+		all = append(all, cat)
+	}
+
+	return merge(sources, all), nil
 }
 
-func Fetch() (*Catalog, error) {
-	client := &http.Client{Timeout: 40 * time.Second}
-	r, err := client.Get(catalogURL)
+func fetchSource(src Source, insecure bool) (*Catalog, error) {
+	idx := src.indexLocation()
+
+	data, err := FetchManifestFromUrl(idx)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Body.Close()
+
+	if !insecure && len(src.PublicKey) > 0 {
+		sig, err := FetchManifestFromUrl(idx + ".sig")
+		if err != nil {
+			return nil, fmt.Errorf("fetching catalog signature: %w", err)
+		}
+
+		if err := VerifySignature(src.PublicKey, data, sig); err != nil {
+			return nil, fmt.Errorf("verifying catalog '%s': %w", idx, err)
+		}
+	}
 
 	target := &Catalog{}
-	err = json.NewDecoder(r.Body).Decode(target)
+	err = json.NewDecoder(bytes.NewReader(data)).Decode(target)
 	if err != nil {
 		return nil, err
 	}
+
+	signed := !insecure && len(src.PublicKey) > 0
+	for i := range target.Items {
+		target.Items[i].signed = signed
+		target.Items[i].Source = src.Name
+		target.Items[i].Manifest = resolveLocation(idx, target.Items[i].Manifest)
+	}
+
 	return target, nil
 }
 
-func FetchManifest(info *PackageInfo) ([]byte, error) {
-	client := &http.Client{Timeout: 40 * time.Second}
-	r, err := client.Get(info.Manifest)
+// FetchManifest downloads the package manifest, verifies its digest
+// and replaces the VERSION placeholder with the package version.
+func FetchManifest(info *PackageInfo, opts FetchOpts) ([]byte, error) {
+	data, err := FetchPackageFile(info, path.Base(info.Manifest), opts)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Body.Close()
 
-	data, err := ioutil.ReadAll(r.Body)
+	res := strings.ReplaceAll(string(data), "VERSION", info.Version)
+	return []byte(res), nil
+}
+
+// FetchPackageFile downloads a file stored next to the package manifest
+// (i.e. controller-config.yaml) and verifies its digest.
+func FetchPackageFile(info *PackageInfo, name string, opts FetchOpts) ([]byte, error) {
+	url := strings.TrimSuffix(info.Manifest, path.Base(info.Manifest)) + name
+
+	data, err := FetchManifestFromUrl(url)
 	if err != nil {
 		return nil, err
 	}
 
-	res := strings.ReplaceAll(string(data), "VERSION", info.Version)
-	return []byte(res), nil
+	if !opts.InsecureSkipVerify {
+		if err := VerifyDigest(info, url, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
 }
 
 func FetchManifestFromUrl(url string) ([]byte, error) {
-	client := &http.Client{Timeout: 40 * time.Second}
-	r, err := client.Get(url)
-	if err != nil {
-		return nil, err
+	if isLocal(url) {
+		return os.ReadFile(strings.TrimPrefix(url, fileScheme))
 	}
-	defer r.Body.Close()
 
-	data, err := ioutil.ReadAll(r.Body)
-	if err != nil {
+	buf := &bytes.Buffer{}
+	if err := httputils.Fetch(url, buf); err != nil {
 		return nil, err
 	}
 
-	return data, nil
+	return buf.Bytes(), nil
 }
 
 type FilterFunc func(PackageInfo) bool
@@ -90,8 +169,58 @@ func IsAPackage() FilterFunc {
 	}
 }
 
-func FilterBy(criteria FilterFunc) (*Catalog, error) {
-	all, err := Fetch()
+// All accepts every catalog entry.
+func All() FilterFunc {
+	return func(info PackageInfo) bool {
+		return true
+	}
+}
+
+// Matching accepts entries whose name or description contains term (case insensitive).
+func Matching(term string) FilterFunc {
+	term = strings.ToLower(term)
+	return func(info PackageInfo) bool {
+		return strings.Contains(strings.ToLower(info.Name), term) ||
+			strings.Contains(strings.ToLower(info.Description), term)
+	}
+}
+
+// IsManagement tells whether the entry belongs to the management plane:
+// flagged as management or listed in ManagementPackages.
+func (p PackageInfo) IsManagement() bool {
+	if p.Management {
+		return true
+	}
+
+	name := slugify.Slugify(p.Name)
+	for _, el := range ManagementPackages {
+		if name == el {
+			return true
+		}
+	}
+	return false
+}
+
+// Kind returns "package" for Crossplane configurations and "provider" otherwise.
+func (p PackageInfo) Kind() string {
+	if IsAPackage()(p) {
+		return "package"
+	}
+	return "provider"
+}
+
+// Find returns the catalog entry with the given (slugified) name.
+func (c *Catalog) Find(name string) *PackageInfo {
+	for i := range c.Items {
+		if c.Items[i].Name == name || slugify.Slugify(c.Items[i].Name) == name {
+			return &c.Items[i]
+		}
+	}
+	return nil
+}
+
+func FilterBy(opts FetchOpts, criteria FilterFunc) (*Catalog, error) {
+	all, err := Fetch(opts)
 	if err != nil {
 		return nil, err
 	}
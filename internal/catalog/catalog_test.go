@@ -10,7 +10,7 @@ import (
 )
 
 func TestFetch(t *testing.T) {
-	all, err := Fetch()
+	all, err := Fetch(FetchOpts{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -21,12 +21,12 @@ func TestFetch(t *testing.T) {
 }
 
 func TestPackagesToInstall(t *testing.T) {
-	all, err := Fetch()
+	all, err := Fetch(FetchOpts{})
 
 	assert.Nil(t, err, "expecting nil error")
 	assert.NotNil(t, all, "expecting non-nil result")
 
-	toInstall, err := FilterBy(ForCLI())
+	toInstall, err := FilterBy(FetchOpts{}, ForCLI())
 	assert.Nil(t, err, "expecting nil error")
 	assert.NotNil(t, toInstall, "expecting non-nil result")
 
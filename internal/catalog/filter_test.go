@@ -0,0 +1,48 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatching(t *testing.T) {
+	info := PackageInfo{Name: "provider-helm", Description: "Crossplane Helm provider"}
+
+	tests := []struct {
+		term string
+		want bool
+	}{
+		{"helm", true},
+		{"HELM", true},
+		{"provider-", true},
+		{"crossplane helm", true},
+		{"", true},
+		{"kubernetes", false},
+		{"helm-provider", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.term, func(t *testing.T) {
+			assert.Equal(t, tc.want, Matching(tc.term)(info))
+		})
+	}
+}
+
+func TestIsManagement(t *testing.T) {
+	tests := []struct {
+		info PackageInfo
+		want bool
+	}{
+		{PackageInfo{Name: "provider-kubernetes"}, true},
+		{PackageInfo{Name: "Provider Helm"}, true},
+		{PackageInfo{Name: "argocd-package", Management: true}, true},
+		{PackageInfo{Name: "provider-sql"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.info.Name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.info.IsManagement())
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package catalog
+
+import "strings"
+
+// Installed indexes the versions of the installed Crossplane packages by
+// name and by image repository; a nil Installed means the installed
+// packages are unknown (i.e. the cluster is not reachable).
+type Installed map[string]string
+
+// Add indexes the package installed with the image.
+func (in Installed) Add(name, image string) {
+	repo, tag := SplitImage(image)
+
+	in[name] = tag
+	if len(repo) > 0 {
+		in[repo] = tag
+	}
+}
+
+// Version returns the installed version of the entry, matched by name or
+// image repository: "n/a" when unknown, "-" when not installed and "yes"
+// when installed without a tag.
+func (in Installed) Version(info PackageInfo) string {
+	if in == nil {
+		return "n/a"
+	}
+
+	repo, _ := SplitImage(info.Image)
+	for _, key := range []string{info.Name, repo} {
+		if ver, ok := in[key]; ok && len(key) > 0 {
+			if len(ver) == 0 {
+				return "yes"
+			}
+			return ver
+		}
+	}
+
+	return "-"
+}
+
+// SplitImage splits an image reference into repository and tag (or digest).
+func SplitImage(img string) (string, string) {
+	if idx := strings.Index(img, "@"); idx != -1 {
+		return img[:idx], img[idx+1:]
+	}
+
+	idx := strings.LastIndex(img, ":")
+	if idx == -1 || idx < strings.LastIndex(img, "/") {
+		return img, ""
+	}
+
+	return img[:idx], img[idx+1:]
+}
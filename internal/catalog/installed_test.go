@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstalledVersion(t *testing.T) {
+	installed := Installed{}
+	installed.Add("provider-helm", "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0")
+	installed.Add("renamed-kubernetes", "xpkg.upbound.io/crossplane-contrib/provider-kubernetes:v0.9.0")
+	installed.Add("provider-untagged", "registry.corp:5000/provider-untagged")
+	installed.Add("package-pinned", "registry.corp/package-pinned@sha256:abc")
+
+	tests := []struct {
+		name      string
+		installed Installed
+		info      PackageInfo
+		want      string
+	}{
+		{"by name", installed, PackageInfo{Name: "provider-helm"}, "v0.15.0"},
+		{"by repository", installed, PackageInfo{Name: "provider-kubernetes", Image: "xpkg.upbound.io/crossplane-contrib/provider-kubernetes:v0.10.0"}, "v0.9.0"},
+		{"without tag", installed, PackageInfo{Name: "provider-untagged"}, "yes"},
+		{"by digest", installed, PackageInfo{Name: "package-pinned"}, "sha256:abc"},
+		{"not installed", installed, PackageInfo{Name: "provider-sql", Image: "xpkg.upbound.io/crossplane-contrib/provider-sql:v0.5.0"}, "-"},
+		{"no image", installed, PackageInfo{Name: "provider-sql"}, "-"},
+		{"unknown", nil, PackageInfo{Name: "provider-helm"}, "n/a"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.installed.Version(tc.info))
+		})
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image, repo, tag string
+	}{
+		{"xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0", "xpkg.upbound.io/crossplane-contrib/provider-helm", "v0.15.0"},
+		{"registry.corp:5000/provider-helm", "registry.corp:5000/provider-helm", ""},
+		{"registry.corp:5000/provider-helm:v1", "registry.corp:5000/provider-helm", "v1"},
+		{"registry.corp/provider-helm@sha256:abc", "registry.corp/provider-helm", "sha256:abc"},
+		{"", "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.image, func(t *testing.T) {
+			repo, tag := SplitImage(tc.image)
+			assert.Equal(t, tc.repo, repo)
+			assert.Equal(t, tc.tag, tag)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package catalog
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Machiel/slugify"
+)
+
+const (
+	// PublicSourceName is the name of the PortalNOW public catalog source.
+	PublicSourceName = "public"
+
+	indexFileName = "index.json"
+	fileScheme    = "file://"
+	keyOption     = "key="
+)
+
+// Source is a catalog index. When the same package name is published
+// by more sources, the entry from the source with the highest priority
+// wins; on equal priority the first declared source wins.
+type Source struct {
+	Name string
+	// Location is the URL or the local path of the index (a directory
+	// is expanded to its index.json).
+	Location string
+	Priority int
+	// PublicKey is the minisign public key used to verify the index
+	// detached signature (<Location>.sig); no signature check when empty.
+	PublicKey string
+}
+
+// ParseSource parses a source in the 'name=location[,priority][,key=publickey]'
+// form, publickey being the base64 line of a minisign public key.
+func ParseSource(s string) (Source, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return Source{}, fmt.Errorf("invalid catalog source '%s' (expected name=location[,priority][,key=publickey])", s)
+	}
+
+	res := Source{Name: parts[0], Location: parts[1]}
+
+	if idx := strings.LastIndex(res.Location, ","); idx != -1 && strings.HasPrefix(res.Location[idx+1:], keyOption) {
+		res.PublicKey = strings.TrimPrefix(res.Location[idx+1:], keyOption)
+		res.Location = res.Location[:idx]
+		if len(res.PublicKey) == 0 {
+			return Source{}, fmt.Errorf("empty public key in catalog source '%s'", s)
+		}
+	}
+
+	if idx := strings.LastIndex(res.Location, ","); idx != -1 {
+		prio, err := strconv.Atoi(res.Location[idx+1:])
+		if err != nil {
+			return Source{}, fmt.Errorf("invalid priority in catalog source '%s': %w", s, err)
+		}
+		res.Priority = prio
+		res.Location = res.Location[:idx]
+	}
+
+	return res, nil
+}
+
+// ParseSources parses a list of sources in the 'name=location[,priority][,key=publickey]' form.
+func ParseSources(all []string) ([]Source, error) {
+	res := make([]Source, 0, len(all))
+	for _, el := range all {
+		src, err := ParseSource(el)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, src)
+	}
+	return res, nil
+}
+
+func (s Source) indexLocation() string {
+	if isLocal(s.Location) {
+		fn := strings.TrimPrefix(s.Location, fileScheme)
+		if fi, err := os.Stat(fn); err == nil && fi.IsDir() {
+			return filepath.Join(fn, indexFileName)
+		}
+		return fn
+	}
+	return s.Location
+}
+
+// sources returns the public catalog followed by the configured ones;
+// a source named 'public' replaces the default public catalog.
+func (o FetchOpts) sources() []Source {
+	public := Source{
+		Name:      PublicSourceName,
+		Location:  catalogURL,
+		PublicKey: o.PublicKey,
+	}
+	if len(o.URL) > 0 {
+		public.Location = o.URL
+	}
+
+	res := []Source{}
+	for _, el := range o.Sources {
+		if el.Name == PublicSourceName {
+			if len(el.PublicKey) == 0 {
+				el.PublicKey = public.PublicKey
+			}
+			public = el
+			continue
+		}
+		res = append(res, el)
+	}
+
+	return append([]Source{public}, res...)
+}
+
+// merge combines the sources items: on name collision the item of the
+// source with higher priority overrides the other one.
+func merge(sources []Source, all []*Catalog) *Catalog {
+	type entry struct {
+		info     PackageInfo
+		priority int
+	}
+
+	keys := []string{}
+	seen := map[string]*entry{}
+	for i, cat := range all {
+		prio := sources[i].Priority
+		for _, el := range cat.Items {
+			key := slugify.Slugify(el.Name)
+
+			cur, ok := seen[key]
+			if !ok {
+				keys = append(keys, key)
+				seen[key] = &entry{info: el, priority: prio}
+				continue
+			}
+
+			if prio > cur.priority {
+				seen[key] = &entry{info: el, priority: prio}
+			}
+		}
+	}
+
+	res := &Catalog{Items: make([]PackageInfo, 0, len(keys))}
+	for _, key := range keys {
+		res.Items = append(res.Items, seen[key].info)
+	}
+
+	return res
+}
+
+func isLocal(location string) bool {
+	if strings.HasPrefix(location, fileScheme) {
+		return true
+	}
+
+	u, err := url.Parse(location)
+	return err != nil || (u.Scheme != "http" && u.Scheme != "https")
+}
+
+// resolveLocation resolves a manifest location relative to the index.
+func resolveLocation(index, location string) string {
+	if len(location) == 0 {
+		return location
+	}
+
+	if isLocal(index) {
+		fn := strings.TrimPrefix(location, fileScheme)
+		if isLocal(location) && !filepath.IsAbs(fn) {
+			return filepath.Join(filepath.Dir(index), fn)
+		}
+		return location
+	}
+
+	base, err := url.Parse(index)
+	if err != nil {
+		return location
+	}
+
+	ref, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	return base.ResolveReference(ref).String()
+}
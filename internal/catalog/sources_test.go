@@ -0,0 +1,88 @@
+package catalog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSource(t *testing.T) {
+	src, err := ParseSource("internal=https://catalog.corp/index.json,100")
+	assert.Nil(t, err, "expecting nil error parsing source")
+	assert.Equal(t, Source{Name: "internal", Location: "https://catalog.corp/index.json", Priority: 100}, src)
+
+	src, err = ParseSource("local=/opt/catalog")
+	assert.Nil(t, err, "expecting nil error parsing source")
+	assert.Equal(t, Source{Name: "local", Location: "/opt/catalog"}, src)
+
+	src, err = ParseSource("internal=https://catalog.corp/index.json,100,key=RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3")
+	assert.Nil(t, err, "expecting nil error parsing source with key")
+	assert.Equal(t, Source{Name: "internal", Location: "https://catalog.corp/index.json", Priority: 100,
+		PublicKey: "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"}, src)
+
+	src, err = ParseSource("local=/opt/catalog,key=RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3")
+	assert.Nil(t, err, "expecting nil error parsing source with key and no priority")
+	assert.Equal(t, "/opt/catalog", src.Location)
+	assert.Equal(t, 0, src.Priority)
+
+	_, err = ParseSource("local=/opt/catalog,key=")
+	assert.NotNil(t, err, "expecting error with empty key")
+
+	_, err = ParseSource("/opt/catalog")
+	assert.NotNil(t, err, "expecting error without name")
+
+	_, err = ParseSource("local=/opt/catalog,high")
+	assert.NotNil(t, err, "expecting error with invalid priority")
+}
+
+func TestFetchSources(t *testing.T) {
+	public, err := json.Marshal(Catalog{Items: []PackageInfo{
+		{Name: "provider-helm", Version: "v0.10.0", Manifest: "helm/provider.yaml"},
+		{Name: "core-package", Version: "v1.0.0", Manifest: "core/package.yaml"},
+	}})
+	assert.Nil(t, err, "expecting nil error encoding public index")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(public)
+	}))
+	defer srv.Close()
+
+	internal, err := json.Marshal(Catalog{Items: []PackageInfo{
+		{Name: "provider-helm", Version: "v0.11.0-corp", Manifest: "helm/provider.yaml"},
+		{Name: "corp-package", Version: "v2.0.0", Manifest: "corp/package.yaml"},
+	}})
+	assert.Nil(t, err, "expecting nil error encoding internal index")
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, indexFileName), internal, 0o644)
+	assert.Nil(t, err, "expecting nil error writing local index")
+
+	all, err := Fetch(FetchOpts{
+		URL:     srv.URL + "/index.json",
+		Sources: []Source{{Name: "internal", Location: dir, Priority: 10}},
+	})
+	assert.Nil(t, err, "expecting nil error fetching sources")
+	assert.Equal(t, 3, len(all.Items))
+
+	helm := all.Find("provider-helm")
+	assert.Equal(t, "internal", helm.Source)
+	assert.Equal(t, "v0.11.0-corp", helm.Version)
+	assert.Equal(t, filepath.Join(dir, "helm", "provider.yaml"), helm.Manifest)
+
+	core := all.Find("core-package")
+	assert.Equal(t, PublicSourceName, core.Source)
+	assert.Equal(t, srv.URL+"/core/package.yaml", core.Manifest)
+
+	// lower priority never overrides the public catalog
+	all, err = Fetch(FetchOpts{
+		URL:     srv.URL + "/index.json",
+		Sources: []Source{{Name: "internal", Location: dir, Priority: -1}},
+	})
+	assert.Nil(t, err, "expecting nil error fetching sources")
+	assert.Equal(t, PublicSourceName, all.Find("provider-helm").Source)
+}
@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	digestPrefix = "sha256:"
+
+	// minisign algorithm identifiers: "Ed" signs the message itself,
+	// "ED" signs the BLAKE2b-512 hash of the message.
+	algEd       = "Ed"
+	algEdHashed = "ED"
+
+	trustedCommentPrefix = "trusted comment: "
+)
+
+var (
+	ErrMissingDigest    = errors.New("missing digest")
+	ErrDigestMismatch   = errors.New("digest mismatch")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidPublicKey = errors.New("invalid public key")
+)
+
+// Digest returns the catalog digest string ("sha256:<hex>") of data.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+// VerifyDigest checks data downloaded from url against the digest
+// declared in the catalog entry for the file with the same base name.
+// A missing digest is an error only for the entries of signed indexes,
+// unsigned catalogs may not publish digests at all.
+func VerifyDigest(info *PackageInfo, url string, data []byte) error {
+	name := path.Base(url)
+
+	want, ok := info.Digests[name]
+	if !ok || len(want) == 0 {
+		if info.signed {
+			return fmt.Errorf("%w for '%s' in package '%s'", ErrMissingDigest, name, info.Name)
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(want, digestPrefix) {
+		want = digestPrefix + want
+	}
+
+	if got := Digest(data); !strings.EqualFold(got, want) {
+		return fmt.Errorf("%w for '%s' in package '%s' (expected: %s, got: %s)",
+			ErrDigestMismatch, name, info.Name, want, got)
+	}
+
+	return nil
+}
+
+type publicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// parsePublicKey decodes a minisign public key. Both the bare base64
+// key and the two lines file format (with untrusted comment) are accepted.
+func parsePublicKey(s string) (*publicKey, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	enc := strings.TrimSpace(lines[len(lines)-1])
+
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPublicKey, err.Error())
+	}
+
+	if len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != algEd {
+		return nil, ErrInvalidPublicKey
+	}
+
+	res := &publicKey{key: ed25519.PublicKey(raw[10:])}
+	copy(res.keyID[:], raw[2:10])
+
+	return res, nil
+}
+
+// VerifySignature checks msg against a minisign detached signature
+// using the given minisign public key.
+func VerifySignature(pubKey string, msg, sig []byte) error {
+	pk, err := parsePublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{}
+	sc := bufio.NewScanner(bytes.NewReader(sig))
+	for sc.Scan() {
+		lines = append(lines, strings.TrimRight(sc.Text(), "\r"))
+	}
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return fmt.Errorf("%w: malformed signature file", ErrInvalidSignature)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	alg, keyID, signature := string(raw[:2]), raw[2:10], raw[10:]
+	if !bytes.Equal(keyID, pk.keyID[:]) {
+		return fmt.Errorf("%w: signed with a different key", ErrInvalidSignature)
+	}
+
+	switch alg {
+	case algEd:
+	case algEdHashed:
+		sum := blake2b.Sum512(msg)
+		msg = sum[:]
+	default:
+		return fmt.Errorf("%w: unsupported algorithm '%s'", ErrInvalidSignature, alg)
+	}
+
+	if !ed25519.Verify(pk.key, msg, signature) {
+		return ErrInvalidSignature
+	}
+
+	// the global signature covers the signature and the trusted comment
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed global signature", ErrInvalidSignature)
+	}
+
+	comment := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	if !ed25519.Verify(pk.key, append(signature, []byte(comment)...), globalSig) {
+		return fmt.Errorf("%w: trusted comment verification failed", ErrInvalidSignature)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package catalog
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+type testKey struct {
+	id   [8]byte
+	priv ed25519.PrivateKey
+	pub  ed25519.PublicKey
+}
+
+func newTestKey(t *testing.T) *testKey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err, "expecting nil error generating key pair")
+
+	k := &testKey{priv: priv, pub: pub}
+	_, err = rand.Read(k.id[:])
+	assert.Nil(t, err, "expecting nil error generating key id")
+
+	return k
+}
+
+func (k *testKey) publicKey() string {
+	raw := append([]byte(algEd), k.id[:]...)
+	raw = append(raw, k.pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw)
+}
+
+// sign produces a minisign signature file for msg
+func (k *testKey) sign(msg []byte, hashed bool) []byte {
+	alg := algEd
+	if hashed {
+		alg = algEdHashed
+		sum := blake2b.Sum512(msg)
+		msg = sum[:]
+	}
+
+	sig := ed25519.Sign(k.priv, msg)
+	raw := append([]byte(alg), k.id[:]...)
+	raw = append(raw, sig...)
+
+	comment := "timestamp:1666000000"
+	global := ed25519.Sign(k.priv, append(sig, []byte(comment)...))
+
+	return []byte(fmt.Sprintf("untrusted comment: signature\n%s\n%s%s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedCommentPrefix, comment,
+		base64.StdEncoding.EncodeToString(global)))
+}
+
+func TestVerifySignature(t *testing.T) {
+	key := newTestKey(t)
+	msg := []byte(`{"packages":[]}`)
+
+	for _, hashed := range []bool{false, true} {
+		sig := key.sign(msg, hashed)
+		assert.Nil(t, VerifySignature(key.publicKey(), msg, sig), "expecting nil error verifying signature")
+
+		err := VerifySignature(key.publicKey(), []byte(`{"packages":null}`), sig)
+		assert.True(t, errors.Is(err, ErrInvalidSignature), "expecting invalid signature on tampered message")
+	}
+
+	other := newTestKey(t)
+	err := VerifySignature(other.publicKey(), msg, key.sign(msg, true))
+	assert.True(t, errors.Is(err, ErrInvalidSignature), "expecting invalid signature with another key")
+
+	err = VerifySignature("not-a-key", msg, key.sign(msg, true))
+	assert.True(t, errors.Is(err, ErrInvalidPublicKey), "expecting invalid public key")
+}
+
+func TestVerifyDigest(t *testing.T) {
+	data := []byte("kind: Provider")
+	info := &PackageInfo{
+		Name:    "provider-helm",
+		Digests: map[string]string{"provider.yaml": Digest(data)},
+	}
+
+	assert.Nil(t, VerifyDigest(info, "https://example.com/helm/provider.yaml", data))
+
+	err := VerifyDigest(info, "https://example.com/helm/provider.yaml", []byte("kind: Configuration"))
+	assert.True(t, errors.Is(err, ErrDigestMismatch), "expecting digest mismatch")
+
+	// digests are optional in unsigned indexes
+	assert.Nil(t, VerifyDigest(info, "https://example.com/helm/service-account.yaml", data))
+
+	info.signed = true
+	err = VerifyDigest(info, "https://example.com/helm/service-account.yaml", data)
+	assert.True(t, errors.Is(err, ErrMissingDigest), "expecting missing digest")
+}
+
+func TestFetchVerified(t *testing.T) {
+	key := newTestKey(t)
+	manifest := []byte("apiVersion: pkg.crossplane.io/v1\nkind: Configuration\nspec:\n  package: core:VERSION\n")
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	idx, err := json.Marshal(Catalog{Items: []PackageInfo{{
+		Name:     "core-package",
+		Version:  "v1.0.0",
+		Manifest: srv.URL + "/core/package.yaml",
+		Digests:  map[string]string{"package.yaml": Digest(manifest)},
+	}}})
+	assert.Nil(t, err, "expecting nil error encoding index")
+
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(idx)
+	})
+	mux.HandleFunc("/index.json.sig", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(key.sign(idx, true))
+	})
+	mux.HandleFunc("/core/package.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(manifest)
+	})
+
+	opts := FetchOpts{URL: srv.URL + "/index.json", PublicKey: key.publicKey()}
+
+	all, err := Fetch(opts)
+	assert.Nil(t, err, "expecting nil error fetching signed catalog")
+	assert.Equal(t, 1, len(all.Items))
+
+	data, err := FetchManifest(&all.Items[0], opts)
+	assert.Nil(t, err, "expecting nil error fetching verified manifest")
+	assert.Contains(t, string(data), "core:v1.0.0")
+
+	all.Items[0].Digests["package.yaml"] = Digest([]byte("something else"))
+	_, err = FetchManifest(&all.Items[0], opts)
+	assert.True(t, errors.Is(err, ErrDigestMismatch), "expecting digest mismatch")
+
+	_, err = FetchManifest(&all.Items[0], FetchOpts{InsecureSkipVerify: true})
+	assert.Nil(t, err, "expecting nil error skipping verification")
+
+	_, err = Fetch(FetchOpts{URL: opts.URL, PublicKey: newTestKey(t).publicKey()})
+	assert.True(t, errors.Is(err, ErrInvalidSignature), "expecting invalid signature with another key")
+}
+
+func TestFetchWithoutDigests(t *testing.T) {
+	manifest := []byte("apiVersion: pkg.crossplane.io/v1\nkind: Provider\nspec:\n  package: helm:VERSION\n")
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	idx, err := json.Marshal(Catalog{Items: []PackageInfo{{
+		Name:     "provider-helm",
+		Version:  "v0.15.0",
+		Manifest: srv.URL + "/helm/provider.yaml",
+	}}})
+	assert.Nil(t, err, "expecting nil error encoding index")
+
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(idx)
+	})
+	mux.HandleFunc("/helm/provider.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(manifest)
+	})
+
+	// unsigned catalog: no digests to check
+	opts := FetchOpts{URL: srv.URL + "/index.json"}
+	all, err := Fetch(opts)
+	assert.Nil(t, err, "expecting nil error fetching unsigned catalog")
+
+	data, err := FetchManifest(&all.Items[0], opts)
+	assert.Nil(t, err, "expecting nil error fetching manifest without digest")
+	assert.Contains(t, string(data), "helm:v0.15.0")
+
+	// signed catalog: the digests are required
+	key := newTestKey(t)
+	mux.HandleFunc("/index.json.sig", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(key.sign(idx, false))
+	})
+
+	opts.PublicKey = key.publicKey()
+	all, err = Fetch(opts)
+	assert.Nil(t, err, "expecting nil error fetching signed catalog")
+
+	_, err = FetchManifest(&all.Items[0], opts)
+	assert.True(t, errors.Is(err, ErrMissingDigest), "expecting missing digest in signed catalog")
+}
+
+func TestFetchSignedSource(t *testing.T) {
+	public, err := json.Marshal(Catalog{Items: []PackageInfo{{Name: "provider-helm", Manifest: "helm/provider.yaml"}}})
+	assert.Nil(t, err, "expecting nil error encoding public index")
+
+	internal, err := json.Marshal(Catalog{Items: []PackageInfo{{Name: "corp-package", Manifest: "corp/package.yaml"}}})
+	assert.Nil(t, err, "expecting nil error encoding internal index")
+
+	key := newTestKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(public)
+	})
+	mux.HandleFunc("/internal/index.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(internal)
+	})
+	mux.HandleFunc("/internal/index.json.sig", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(key.sign(internal, true))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// the public index is not signed, the internal one with its own key
+	src := Source{Name: "internal", Location: srv.URL + "/internal/index.json", Priority: 10, PublicKey: key.publicKey()}
+	all, err := Fetch(FetchOpts{URL: srv.URL + "/index.json", Sources: []Source{src}})
+	assert.Nil(t, err, "expecting nil error fetching signed source")
+	assert.False(t, all.Find("provider-helm").signed)
+	assert.True(t, all.Find("corp-package").signed)
+
+	src.PublicKey = newTestKey(t).publicKey()
+	_, err = Fetch(FetchOpts{URL: srv.URL + "/index.json", Sources: []Source{src}})
+	assert.True(t, errors.Is(err, ErrInvalidSignature), "expecting invalid signature with another key")
+}
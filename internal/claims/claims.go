@@ -53,7 +53,7 @@ func (c GitOps) getName() string {
 	return c.name
 }
 
-func (c GitOps) SetName(s string) {
+func (c *GitOps) SetName(s string) {
 	c.name = s
 }
 
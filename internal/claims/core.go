@@ -47,7 +47,7 @@ func (c Core) Delete(ctx context.Context, opts DeleteOpts) error {
 		}
 	}
 
-	return err
+	return nil
 }
 
 func (c Core) GetGroupVersionKind() schema.GroupVersionKind {
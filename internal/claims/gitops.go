@@ -17,7 +17,7 @@ func (g GitOps) Apply(ctx context.Context, opts ModuleOpts) error {
 	obj := &unstructured.Unstructured{}
 	obj.SetKind(gvk.Kind)
 	obj.SetAPIVersion(gvk.GroupVersion().String())
-	obj.SetName("gitops")
+	obj.SetName(g.name)
 	obj.SetLabels(map[string]string{
 		core.InstalledByLabel: core.InstalledByValue,
 	})
@@ -48,7 +48,7 @@ func (g GitOps) Delete(ctx context.Context, opts DeleteOpts) error {
 		}
 	}
 
-	return err
+	return nil
 }
 
 func (g GitOps) GetGroupVersionKind() schema.GroupVersionKind {
@@ -0,0 +1,108 @@
+package claims
+
+import (
+	"context"
+	"fmt"
+
+	xpextv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Module is the ManagedResource of any installed composite resource
+// definition: its namespaced claim or, when the definition does not offer
+// claims, its cluster scoped composite resource.
+type Module struct {
+	name      string
+	namespace string
+	gvk       schema.GroupVersionKind
+	resource  string
+}
+
+// NewModule returns the module named name defined by xrd; namespace is
+// ignored for composite resources.
+func NewModule(xrd *xpextv1.CompositeResourceDefinition, name, namespace string) (*Module, error) {
+	res := &Module{name: name}
+
+	if xrd.OffersClaim() {
+		res.gvk = xrd.GetClaimGroupVersionKind()
+		res.resource = xrd.Spec.ClaimNames.Plural
+		res.namespace = namespace
+	} else {
+		res.gvk = xrd.GetCompositeGroupVersionKind()
+		res.resource = xrd.Spec.Names.Plural
+	}
+
+	if len(res.gvk.Version) == 0 {
+		return nil, fmt.Errorf("composite resource definition '%s' has no referenceable version", xrd.GetName())
+	}
+
+	return res, nil
+}
+
+func (m Module) getName() string {
+	return m.name
+}
+
+// Name returns the claim name.
+func (m Module) Name() string {
+	return m.name
+}
+
+// Namespace returns the claim namespace, empty for composite resources.
+func (m Module) Namespace() string {
+	return m.namespace
+}
+
+func (m Module) Apply(ctx context.Context, opts ModuleOpts) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(m.gvk)
+	obj.SetName(m.name)
+	obj.SetNamespace(m.namespace)
+	obj.SetLabels(map[string]string{
+		core.InstalledByLabel: core.InstalledByValue,
+	})
+	err := unstructured.SetNestedField(obj.Object, opts.Data, "spec")
+	if err != nil {
+		return err
+	}
+
+	return core.Apply(ctx, core.ApplyOpts{
+		RESTConfig: opts.RESTConfig,
+		GVK:        m.gvk,
+		Object:     obj,
+	})
+}
+
+func (m Module) Delete(ctx context.Context, opts DeleteOpts) error {
+	dc, err := dynamic.NewForConfig(opts.RESTConfig)
+	if err != nil {
+		return err
+	}
+
+	var ri dynamic.ResourceInterface = dc.Resource(m.GetGroupVersionResource())
+	if len(m.namespace) > 0 {
+		ri = dc.Resource(m.GetGroupVersionResource()).Namespace(m.namespace)
+	}
+
+	err = ri.Delete(ctx, opts.Name, metav1.DeleteOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m Module) GetGroupVersionKind() schema.GroupVersionKind {
+	return m.gvk
+}
+
+func (m Module) GetGroupVersionResource() schema.GroupVersionResource {
+	return m.gvk.GroupVersion().WithResource(m.resource)
+}
@@ -0,0 +1,50 @@
+package claims
+
+import (
+	"testing"
+
+	xpextv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestNewModule(t *testing.T) {
+	xrd := &xpextv1.CompositeResourceDefinition{}
+	xrd.SetName("gitops.pkg.platformnow.io")
+	xrd.Spec.Group = "pkg.platformnow.io"
+	xrd.Spec.Names = extv1.CustomResourceDefinitionNames{Kind: "Gitops", Plural: "gitops"}
+	xrd.Spec.Versions = []xpextv1.CompositeResourceDefinitionVersion{
+		{Name: "v1alpha1", Served: true},
+		{Name: "v1", Served: true, Referenceable: true},
+	}
+
+	mod, err := NewModule(xrd, "gitops", "landscape-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mod.GetGroupVersionResource().String(); got != "pkg.platformnow.io/v1, Resource=gitops" {
+		t.Fatalf("unexpected composite resource: %s", got)
+	}
+	if len(mod.Namespace()) > 0 {
+		t.Fatalf("expected cluster scoped composite, got namespace: %s", mod.Namespace())
+	}
+
+	xrd.Spec.ClaimNames = &extv1.CustomResourceDefinitionNames{Kind: "GitopsClaim", Plural: "gitopsclaims"}
+
+	mod, err = NewModule(xrd, "gitops", "landscape-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mod.GetGroupVersionKind().Kind; got != "GitopsClaim" {
+		t.Fatalf("expected claim kind, got: %s", got)
+	}
+	if mod.Namespace() != "landscape-system" {
+		t.Fatalf("expected claim namespace, got: %s", mod.Namespace())
+	}
+
+	xrd.Spec.Versions[1].Referenceable = false
+	if _, err := NewModule(xrd, "gitops", ""); err == nil {
+		t.Fatal("expected error without referenceable version")
+	}
+}
@@ -0,0 +1,244 @@
+package claims
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// maxTreeDepth bounds the composition nesting followed by BuildTree.
+const maxTreeDepth = 8
+
+// Node is an object of a claim tree: the claim, its composite and the
+// composed resources, nested when they are composites too.
+type Node struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	// Synced and Ready are the condition statuses ("True", "False",
+	// "Unknown"), empty when the object has no such condition.
+	Synced string
+	Ready  string
+	// Message explains why the object is not synced or ready.
+	Message string
+	// Missing is true when the referenced object does not exist (yet).
+	Missing  bool
+	Children []*Node
+}
+
+// IsReady returns true when the object is ready; objects without a Ready
+// condition (i.e. plain Kubernetes objects) are ready once they exist.
+func (n *Node) IsReady() bool {
+	if n.Missing {
+		return false
+	}
+	return n.Ready == "True" || (len(n.Ready) == 0 && n.Synced != "False")
+}
+
+// Count returns the number of ready nodes and the total number of nodes.
+func (n *Node) Count() (ready, total int) {
+	total = 1
+	if n.IsReady() {
+		ready = 1
+	}
+	for _, el := range n.Children {
+		r, t := el.Count()
+		ready += r
+		total += t
+	}
+	return ready, total
+}
+
+// Blocking returns the deepest nodes not ready: the leaves not ready and
+// the nodes not ready whose children are all ready.
+func (n *Node) Blocking() []*Node {
+	res := []*Node{}
+	for _, el := range n.Children {
+		res = append(res, el.Blocking()...)
+	}
+	if len(res) == 0 && !n.IsReady() {
+		res = append(res, n)
+	}
+	return res
+}
+
+// String returns kind/name.
+func (n *Node) String() string {
+	return fmt.Sprintf("%s/%s", n.Kind, n.Name)
+}
+
+// Status returns the conditions summary of the node.
+func (n *Node) Status() string {
+	if n.Missing {
+		return "Missing"
+	}
+
+	res := fmt.Sprintf("Synced=%s Ready=%s", valueOrUnknown(n.Synced), valueOrUnknown(n.Ready))
+	if len(n.Message) > 0 {
+		res = fmt.Sprintf("%s: %s", res, n.Message)
+	}
+	return res
+}
+
+// Render writes the tree, one node per line.
+func (n *Node) Render(w io.Writer) {
+	fmt.Fprintf(w, "%s  %s\n", n, n.Status())
+	n.renderChildren(w, "")
+}
+
+func (n *Node) renderChildren(w io.Writer, indent string) {
+	for i, el := range n.Children {
+		branch, next := "├─ ", "│  "
+		if i == len(n.Children)-1 {
+			branch, next = "└─ ", "   "
+		}
+		fmt.Fprintf(w, "%s%s%s  %s\n", indent, branch, el, el.Status())
+		el.renderChildren(w, indent+next)
+	}
+}
+
+// BuildTree returns the tree rooted at obj following the claim resourceRef
+// and the composite resourceRefs.
+func BuildTree(ctx context.Context, rc *rest.Config, obj *unstructured.Unstructured) (*Node, error) {
+	return buildTree(ctx, rc, obj, 0)
+}
+
+func buildTree(ctx context.Context, rc *rest.Config, obj *unstructured.Unstructured, depth int) (*Node, error) {
+	res := newNode(obj)
+	if depth >= maxTreeDepth {
+		return res, nil
+	}
+
+	for _, ref := range ResourceRefs(obj) {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := core.Get(ctx, core.GetOpts{
+			RESTConfig: rc,
+			GVK:        gv.WithKind(ref.Kind),
+			Name:       ref.Name,
+			Namespace:  ref.Namespace,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if child == nil {
+			res.Children = append(res.Children, &Node{
+				APIVersion: ref.APIVersion,
+				Kind:       ref.Kind,
+				Name:       ref.Name,
+				Namespace:  ref.Namespace,
+				Missing:    true,
+			})
+			continue
+		}
+
+		node, err := buildTree(ctx, rc, child, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		res.Children = append(res.Children, node)
+	}
+
+	return res, nil
+}
+
+// ObjectRef references an object of the tree.
+type ObjectRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// ResourceRefs returns the composite referenced by a claim (spec.resourceRef)
+// and the resources composed by a composite (spec.resourceRefs).
+func ResourceRefs(obj *unstructured.Unstructured) []ObjectRef {
+	all := []interface{}{}
+	if ref, ok, _ := unstructured.NestedMap(obj.Object, "spec", "resourceRef"); ok {
+		all = append(all, ref)
+	}
+	if refs, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "resourceRefs"); ok {
+		all = append(all, refs...)
+	}
+
+	res := []ObjectRef{}
+	for _, el := range all {
+		m, ok := el.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var ref ObjectRef
+		if err := core.FromUnstructuredViaJSON(m, &ref); err != nil || len(ref.Name) == 0 {
+			// composed resources not created yet have no name
+			continue
+		}
+		res = append(res, ref)
+	}
+
+	return res
+}
+
+func newNode(obj *unstructured.Unstructured) *Node {
+	res := &Node{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+	}
+	res.Synced, res.Ready, res.Message = Conditions(obj)
+
+	return res
+}
+
+// Conditions returns the status of the Synced and Ready conditions of obj
+// (empty when missing) and the messages of those not True.
+func Conditions(obj *unstructured.Unstructured) (synced, ready, message string) {
+	conds, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	messages := []string{}
+	for _, el := range conds {
+		cond, ok := el.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		status, _ := cond["status"].(string)
+		switch cond["type"] {
+		case "Synced":
+			synced = status
+		case string(TypeReady):
+			ready = status
+		default:
+			continue
+		}
+
+		if status == "True" {
+			continue
+		}
+		if msg, _ := cond["message"].(string); len(msg) > 0 {
+			messages = append(messages, msg)
+		} else if reason, _ := cond["reason"].(string); len(reason) > 0 {
+			messages = append(messages, reason)
+		}
+	}
+
+	return synced, ready, strings.Join(messages, "; ")
+}
+
+func valueOrUnknown(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package claims
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewNode(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "helm.crossplane.io/v1beta1",
+		"kind":       "Release",
+		"metadata":   map[string]interface{}{"name": "core-argo-cd"},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Synced", "status": "True", "reason": "ReconcileSuccess"},
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "Unavailable", "message": "pods not ready"},
+			},
+		},
+	}}
+
+	n := newNode(obj)
+	if n.Synced != "True" || n.Ready != "False" {
+		t.Fatalf("unexpected conditions: synced=%s ready=%s", n.Synced, n.Ready)
+	}
+	if n.Message != "pods not ready" {
+		t.Fatalf("unexpected message: %s", n.Message)
+	}
+	if n.IsReady() {
+		t.Fatal("expected not ready")
+	}
+}
+
+func TestResourceRefs(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"resourceRefs": []interface{}{
+				map[string]interface{}{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "core-argo-cd"},
+				map[string]interface{}{"apiVersion": "kubernetes.crossplane.io/v1alpha1", "kind": "Object"},
+			},
+		},
+	}}
+
+	refs := ResourceRefs(obj)
+	if len(refs) != 1 || refs[0].Name != "core-argo-cd" {
+		t.Fatalf("expected only the named ref, got: %+v", refs)
+	}
+}
+
+func TestTreeBlocking(t *testing.T) {
+	tree := &Node{
+		Kind: "Core", Name: "core", Synced: "True", Ready: "False",
+		Children: []*Node{
+			{Kind: "Release", Name: "argo", Synced: "True", Ready: "True"},
+			{
+				Kind: "Gitops", Name: "core-argo-cd", Synced: "True", Ready: "False",
+				Children: []*Node{
+					{Kind: "Object", Name: "repo", Synced: "False", Ready: "False", Message: "cannot apply"},
+					{Kind: "ConfigMap", Name: "plain"},
+				},
+			},
+			{Kind: "Release", Name: "vault", Missing: true},
+		},
+	}
+
+	ready, total := tree.Count()
+	if ready != 2 || total != 6 {
+		t.Fatalf("expected 2/6 ready, got: %d/%d", ready, total)
+	}
+
+	got := []string{}
+	for _, el := range tree.Blocking() {
+		got = append(got, el.String())
+	}
+	if strings.Join(got, ",") != "Object/repo,Release/vault" {
+		t.Fatalf("unexpected blocking nodes: %v", got)
+	}
+
+	err := &NotReadyError{Tree: tree}
+	if !strings.Contains(err.Error(), "Object/repo (Synced=False Ready=False: cannot apply)") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	buf := bytes.Buffer{}
+	tree.Render(&buf)
+	want := `Core/core  Synced=True Ready=False
+├─ Release/argo  Synced=True Ready=True
+├─ Gitops/core-argo-cd  Synced=True Ready=False
+│  ├─ Object/repo  Synced=False Ready=False: cannot apply
+│  └─ ConfigMap/plain  Synced=- Ready=-
+└─ Release/vault  Missing
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected tree:\n%s", buf.String())
+	}
+}
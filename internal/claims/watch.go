@@ -2,13 +2,15 @@ package claims
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/wait"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 )
 
@@ -52,41 +54,124 @@ type ConditionedStatus struct {
 	Conditions []Condition `json:"conditions,omitempty"`
 }
 
-func WaitUntilReady(ctx context.Context, restConfig *rest.Config, resource ManagedResource) error {
-	stopFn := func(et watch.EventType, obj *unstructured.Unstructured) (bool, error) {
-		if obj.GetName() != resource.getName() {
-			return false, nil
+// DefaultWaitInterval is how often Wait refreshes the claim tree.
+const DefaultWaitInterval = 5 * time.Second
+
+type WaitOpts struct {
+	RESTConfig *rest.Config
+	Resource   ManagedResource
+	// Timeout defaults to the claims phase timeout (wait.Defaults).
+	Timeout  time.Duration
+	Interval time.Duration
+	// ProgressFn, if set, is called with the claim tree at every refresh.
+	ProgressFn func(*Node)
+}
+
+// NotReadyError is returned by Wait when the claim is not ready in time.
+type NotReadyError struct {
+	Tree *Node
+	// Err is the error of the last refresh, if it failed.
+	Err error
+}
+
+func (e *NotReadyError) Error() string {
+	blocking := []string{}
+	for _, el := range e.Tree.Blocking() {
+		if el == e.Tree {
+			continue
 		}
+		blocking = append(blocking, fmt.Sprintf("%s (%s)", el, el.Status()))
+	}
+
+	res := fmt.Sprintf("%s not ready, waiting for: %s", e.Tree, strings.Join(blocking, ", "))
+	if len(blocking) == 0 {
+		res = fmt.Sprintf("%s not ready: %s", e.Tree, e.Tree.Status())
+	}
+	if e.Err != nil {
+		res = fmt.Sprintf("%s (last refresh: %s)", res, e.Err.Error())
+	}
+	return res
+}
+
+func (e *NotReadyError) Unwrap() error {
+	return wait.ErrTimeout
+}
+
+// getTree returns the tree of the claim, replaced by the tests.
+var getTree = fetchTree
+
+// Wait polls the claim and its composed resources until the claim is Ready.
+// The refresh errors (i.e. an API server restarting) are retried until the
+// timeout; then it returns a *NotReadyError with the last tree and error.
+func Wait(ctx context.Context, opts WaitOpts) error {
+	opts.Timeout = wait.Timeout(opts.Timeout, wait.PhaseClaims)
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWaitInterval
+	}
 
-		val, ok, err := unstructured.NestedFieldCopy(obj.Object, "status")
+	namespace := ""
+	if el, ok := opts.Resource.(interface{ Namespace() string }); ok {
+		namespace = el.Namespace()
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var last *Node
+	var lastErr error
+	err := wait.Poll(wctx, opts.Interval, func(ctx context.Context) (bool, error) {
+		tree, err := getTree(ctx, opts.RESTConfig, opts.Resource, namespace)
 		if err != nil {
-			return false, err
+			if ctx.Err() != nil {
+				return false, err
+			}
+			lastErr = err
+			return false, nil
 		}
-		if !ok {
+		lastErr = nil
+		if tree == nil {
 			return false, nil
 		}
 
-		var status ConditionedStatus
-		err = core.FromUnstructuredViaJSON(val.(map[string]interface{}), &status)
-		if err != nil {
-			return false, err
+		last = tree
+		if opts.ProgressFn != nil {
+			opts.ProgressFn(tree)
 		}
-		//fmt.Printf("%v\n", status)
-		ready := false
-		for _, cond := range status.Conditions {
-			if cond.Type == TypeReady {
-				ready = (cond.Status == corev1.ConditionTrue)
-			}
+		return tree.IsReady(), nil
+	})
+	if err == nil || ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	if last == nil {
+		if lastErr != nil {
+			return fmt.Errorf("%s '%s' not found: %w: %w", opts.Resource.GetGroupVersionKind().Kind, opts.Resource.getName(), core.ErrWatcherTimeout, lastErr)
 		}
+		return fmt.Errorf("%s '%s' not found: %w", opts.Resource.GetGroupVersionKind().Kind, opts.Resource.getName(), core.ErrWatcherTimeout)
+	}
+	return &NotReadyError{Tree: last, Err: lastErr}
+}
 
-		return ready, nil
+// fetchTree returns the tree of the claim, nil if it does not exist yet.
+func fetchTree(ctx context.Context, rc *rest.Config, resource ManagedResource, namespace string) (*Node, error) {
+	obj, err := core.Get(ctx, core.GetOpts{
+		RESTConfig: rc,
+		GVK:        resource.GetGroupVersionKind(),
+		Name:       resource.getName(),
+		Namespace:  namespace,
+	})
+	if err != nil || obj == nil {
+		return nil, err
 	}
 
-	return core.Watch(ctx, core.WatchOpts{
+	return BuildTree(ctx, rc, obj)
+}
+
+// WaitUntilReady waits with the default timeout for the claim readiness.
+func WaitUntilReady(ctx context.Context, restConfig *rest.Config, resource ManagedResource) error {
+	return Wait(ctx, WaitOpts{
 		RESTConfig: restConfig,
-		GVR:        resource.GetGroupVersionResource(),
-		StopFn:     stopFn,
-		Timeout:    time.Minute * 5,
+		Resource:   resource,
 	})
 }
 
@@ -0,0 +1,63 @@
+package claims
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	xpextv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/platfornow/lash/internal/wait"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestWaitRefreshErrors(t *testing.T) {
+	xrd := &xpextv1.CompositeResourceDefinition{}
+	xrd.SetName("cores.pkg.platformnow.io")
+	xrd.Spec.Group = "pkg.platformnow.io"
+	xrd.Spec.Names = extv1.CustomResourceDefinitionNames{Kind: "Core", Plural: "cores"}
+	xrd.Spec.Versions = []xpextv1.CompositeResourceDefinitionVersion{{Name: "v1", Served: true, Referenceable: true}}
+
+	mod, err := NewModule(xrd, "core", "landscape-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { getTree = fetchTree }()
+
+	unavailable := errors.New("the server is currently unable to handle the request")
+	notReady := &Node{Kind: "Core", Name: "core", Synced: "True", Ready: "False"}
+
+	// a failed refresh is retried
+	calls := 0
+	getTree = func(context.Context, *rest.Config, ManagedResource, string) (*Node, error) {
+		calls++
+		if calls == 1 {
+			return nil, unavailable
+		}
+		return &Node{Kind: "Core", Name: "core", Synced: "True", Ready: "True"}, nil
+	}
+	if err := Wait(context.Background(), WaitOpts{Resource: mod, Timeout: time.Second, Interval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the timeout reports the last refresh error
+	calls = 0
+	getTree = func(context.Context, *rest.Config, ManagedResource, string) (*Node, error) {
+		calls++
+		if calls == 1 {
+			return notReady, nil
+		}
+		return nil, unavailable
+	}
+	err = Wait(context.Background(), WaitOpts{Resource: mod, Timeout: 20 * time.Millisecond, Interval: time.Millisecond})
+
+	var nre *NotReadyError
+	if !errors.As(err, &nre) || !errors.Is(err, wait.ErrTimeout) || nre.Tree != notReady {
+		t.Fatalf("expected the not ready error, got: %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), "(last refresh: "+unavailable.Error()+")") {
+		t.Fatalf("expected the refresh error, got: %s", err)
+	}
+}
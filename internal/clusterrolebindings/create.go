@@ -18,9 +18,16 @@ type CreateOptions struct {
 	Name             string
 	SubjectName      string
 	SubjectNamespace string
+	// Role is the bound ClusterRole, cluster-admin if not set.
+	Role string
 }
 
 func Create(ctx context.Context, opts CreateOptions) error {
+	role := opts.Role
+	if len(role) == 0 {
+		role = "cluster-admin"
+	}
+
 	crb := rbacv1.ClusterRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: map[string]string{
@@ -31,7 +38,7 @@ func Create(ctx context.Context, opts CreateOptions) error {
 		RoleRef: rbacv1.RoleRef{
 			APIGroup: "rbac.authorization.k8s.io",
 			Kind:     "ClusterRole",
-			Name:     "cluster-admin",
+			Name:     role,
 		},
 		Subjects: []rbacv1.Subject{
 			{
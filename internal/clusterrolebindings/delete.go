@@ -28,11 +28,9 @@ func Delete(ctx context.Context, opts DeleteOpts) (err error) {
 	}
 
 	err = dc.Resource(gvr).Delete(ctx, opts.Name, metav1.DeleteOptions{})
-	if err != nil {
-		if !errors.IsNotFound(err) {
-			return err
-		}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
 	}
 
-	return err
+	return nil
 }
@@ -0,0 +1,350 @@
+// Package clusters registers workload clusters on a management cluster,
+// so that Argo CD and the Crossplane kubernetes and helm providers can
+// deploy to them.
+package clusters
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// ClusterLabel holds the name of the registered cluster on every object
+	// created by Register.
+	ClusterLabel = "platformnow.io/cluster"
+	// KubeconfigKey is the Secret key holding the workload cluster kubeconfig.
+	KubeconfigKey = "kubeconfig"
+
+	argoSecretTypeLabel = "argocd.argoproj.io/secret-type"
+)
+
+// ErrUnsupportedAuth is returned for kubeconfig users authenticating with
+// an exec plugin or an auth provider: the command or the provider would
+// run inside the management cluster, where they are not available.
+var ErrUnsupportedAuth = errors.New("exec and auth-provider users cannot be registered, use a ServiceAccount token or client certificates")
+
+var (
+	secretGVK = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+	kubernetesProviderConfigGVK = schema.GroupVersionKind{
+		Group:   "kubernetes.crossplane.io",
+		Version: "v1alpha1",
+		Kind:    "ProviderConfig",
+	}
+
+	helmProviderConfigGVK = schema.GroupVersionKind{
+		Group:   "helm.crossplane.io",
+		Version: "v1alpha1",
+		Kind:    "ProviderConfig",
+	}
+)
+
+// Cluster is a workload cluster registered on the management cluster.
+type Cluster struct {
+	Name   string
+	Server string
+	ArgoCD bool
+}
+
+type RegisterOpts struct {
+	RESTConfig *rest.Config
+	// Name of the workload cluster.
+	Name string
+	// Kubeconfig of the workload cluster.
+	Kubeconfig []byte
+	// Context of Kubeconfig to register; the current one if empty.
+	Context string
+	// Server overrides the API server URL found in Kubeconfig, i.e. when it
+	// is not reachable from the management cluster.
+	Server string
+	// Namespace of the kubeconfig Secret.
+	Namespace string
+	// ArgoCDNamespace is where Argo CD looks for cluster secrets.
+	ArgoCDNamespace string
+}
+
+// Register stores the workload cluster kubeconfig in a Secret and creates
+// the Argo CD cluster secret and the ProviderConfigs of provider-kubernetes
+// and provider-helm referencing it. ProviderConfigs of providers not
+// installed are skipped.
+func Register(ctx context.Context, opts RegisterOpts) error {
+	kubeconfig, err := Minify(opts.Kubeconfig, opts.Context, opts.Server)
+	if err != nil {
+		return err
+	}
+
+	argo, err := ArgoCDSecret(opts.Name, opts.ArgoCDNamespace, kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	all := []*unstructured.Unstructured{
+		KubeconfigSecret(opts.Name, opts.Namespace, kubeconfig),
+		argo,
+	}
+	all = append(all, ProviderConfigs(opts.Name, opts.Namespace)...)
+
+	for _, el := range all {
+		err := core.Apply(ctx, core.ApplyOpts{
+			RESTConfig: opts.RESTConfig,
+			GVK:        el.GroupVersionKind(),
+			Object:     el,
+		})
+		if err != nil {
+			return fmt.Errorf("applying %s '%s': %w", el.GetKind(), el.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+type UnregisterOpts struct {
+	RESTConfig      *rest.Config
+	Name            string
+	Namespace       string
+	ArgoCDNamespace string
+}
+
+// Unregister deletes the objects created by Register.
+func Unregister(ctx context.Context, opts UnregisterOpts) error {
+	all := []*unstructured.Unstructured{
+		newObject(secretGVK, ArgoCDSecretName(opts.Name), opts.ArgoCDNamespace),
+	}
+	all = append(all, ProviderConfigs(opts.Name, opts.Namespace)...)
+	all = append(all, newObject(secretGVK, KubeconfigSecretName(opts.Name), opts.Namespace))
+
+	for _, el := range all {
+		err := core.Delete(ctx, core.DeleteOpts{
+			RESTConfig: opts.RESTConfig,
+			Object:     el,
+		})
+		if err != nil {
+			return fmt.Errorf("deleting %s '%s': %w", el.GetKind(), el.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// List returns the registered clusters sorted by name.
+func List(ctx context.Context, rc *rest.Config, namespace, argoNamespace string) ([]Cluster, error) {
+	secrets, err := core.List(ctx, core.ListOpts{
+		RESTConfig:    rc,
+		GVK:           secretGVK,
+		Namespace:     namespace,
+		LabelSelector: fmt.Sprintf("%s,%s=%s", ClusterLabel, core.InstalledByLabel, core.InstalledByValue),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	argo, err := core.List(ctx, core.ListOpts{
+		RESTConfig:    rc,
+		GVK:           secretGVK,
+		Namespace:     argoNamespace,
+		LabelSelector: fmt.Sprintf("%s=cluster,%s", argoSecretTypeLabel, ClusterLabel),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	registered := map[string]bool{}
+	for _, el := range argo {
+		registered[el.GetLabels()[ClusterLabel]] = true
+	}
+
+	res := []Cluster{}
+	for _, el := range secrets {
+		name := el.GetLabels()[ClusterLabel]
+		if el.GetName() != KubeconfigSecretName(name) {
+			continue
+		}
+
+		cl := Cluster{Name: name, ArgoCD: registered[name]}
+
+		enc, _, _ := unstructured.NestedString(el.Object, "data", KubeconfigKey)
+		if data, err := base64.StdEncoding.DecodeString(enc); err == nil {
+			if rc, err := core.RESTConfigFromBytes(data, ""); err == nil {
+				cl.Server = rc.Host
+			}
+		}
+
+		res = append(res, cl)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res, nil
+}
+
+// KubeconfigSecretName returns the name of the Secret holding the
+// kubeconfig of the named cluster.
+func KubeconfigSecretName(name string) string {
+	return fmt.Sprintf("%s-kubeconfig", name)
+}
+
+// ArgoCDSecretName returns the name of the Argo CD cluster secret of the
+// named cluster.
+func ArgoCDSecretName(name string) string {
+	return fmt.Sprintf("cluster-%s", name)
+}
+
+// Minify returns a self-contained kubeconfig with only the given context
+// (the current one if empty): referenced certificate files are inlined
+// and, when server is not empty, the cluster URL is replaced. Users with
+// an exec plugin or an auth provider are rejected (ErrUnsupportedAuth).
+func Minify(kubeconfig []byte, context, server string) ([]byte, error) {
+	cfg, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(context) > 0 {
+		cfg.CurrentContext = context
+	}
+	if len(cfg.CurrentContext) == 0 {
+		return nil, fmt.Errorf("no context selected and no current context in kubeconfig")
+	}
+
+	if err := clientcmdapi.MinifyConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	for name, el := range cfg.AuthInfos {
+		if el.Exec != nil || el.AuthProvider != nil {
+			return nil, fmt.Errorf("user '%s': %w", name, ErrUnsupportedAuth)
+		}
+	}
+
+	if err := clientcmdapi.FlattenConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	if len(server) > 0 {
+		for _, el := range cfg.Clusters {
+			el.Server = server
+		}
+	}
+
+	return clientcmd.Write(*cfg)
+}
+
+// KubeconfigSecret returns the Secret holding the workload cluster kubeconfig,
+// referenced by the ProviderConfigs.
+func KubeconfigSecret(name, namespace string, kubeconfig []byte) *unstructured.Unstructured {
+	obj := newObject(secretGVK, KubeconfigSecretName(name), namespace)
+	obj.Object["type"] = "Opaque"
+	obj.Object["data"] = map[string]interface{}{
+		KubeconfigKey: base64.StdEncoding.EncodeToString(kubeconfig),
+	}
+	return obj
+}
+
+// argoClusterConfig is the "config" field of an Argo CD cluster secret.
+type argoClusterConfig struct {
+	BearerToken     string              `json:"bearerToken,omitempty"`
+	Username        string              `json:"username,omitempty"`
+	Password        string              `json:"password,omitempty"`
+	TLSClientConfig argoTLSClientConfig `json:"tlsClientConfig"`
+}
+
+type argoTLSClientConfig struct {
+	Insecure   bool   `json:"insecure"`
+	ServerName string `json:"serverName,omitempty"`
+	CAData     []byte `json:"caData,omitempty"`
+	CertData   []byte `json:"certData,omitempty"`
+	KeyData    []byte `json:"keyData,omitempty"`
+}
+
+// ArgoCDSecret returns the Argo CD declarative cluster secret built from
+// the (minified) workload cluster kubeconfig; exec and auth-provider users
+// are rejected (ErrUnsupportedAuth).
+func ArgoCDSecret(name, namespace string, kubeconfig []byte) (*unstructured.Unstructured, error) {
+	rc, err := core.RESTConfigFromBytes(kubeconfig, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if rc.ExecProvider != nil || rc.AuthProvider != nil {
+		return nil, ErrUnsupportedAuth
+	}
+
+	cfg := argoClusterConfig{
+		BearerToken: rc.BearerToken,
+		Username:    rc.Username,
+		Password:    rc.Password,
+		TLSClientConfig: argoTLSClientConfig{
+			Insecure:   rc.Insecure,
+			ServerName: rc.ServerName,
+			CAData:     rc.CAData,
+			CertData:   rc.CertData,
+			KeyData:    rc.KeyData,
+		},
+	}
+
+	dat, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := newObject(secretGVK, ArgoCDSecretName(name), namespace)
+	labels := obj.GetLabels()
+	labels[argoSecretTypeLabel] = "cluster"
+	obj.SetLabels(labels)
+
+	obj.Object["type"] = "Opaque"
+	obj.Object["data"] = map[string]interface{}{
+		"name":   base64.StdEncoding.EncodeToString([]byte(name)),
+		"server": base64.StdEncoding.EncodeToString([]byte(rc.Host)),
+		"config": base64.StdEncoding.EncodeToString(dat),
+	}
+
+	return obj, nil
+}
+
+// ProviderConfigs returns the provider-kubernetes and provider-helm
+// ProviderConfigs, named after the cluster, reading the credentials from
+// the kubeconfig Secret.
+func ProviderConfigs(name, namespace string) []*unstructured.Unstructured {
+	res := []*unstructured.Unstructured{}
+	for _, gvk := range []schema.GroupVersionKind{kubernetesProviderConfigGVK, helmProviderConfigGVK} {
+		obj := newObject(gvk, name, "")
+		obj.Object["spec"] = map[string]interface{}{
+			"credentials": map[string]interface{}{
+				"source": "Secret",
+				"secretRef": map[string]interface{}{
+					"namespace": namespace,
+					"name":      KubeconfigSecretName(name),
+					"key":       KubeconfigKey,
+				},
+			},
+		}
+		res = append(res, obj)
+	}
+	return res
+}
+
+func newObject(gvk schema.GroupVersionKind, name, namespace string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(map[string]string{
+		core.InstalledByLabel: core.InstalledByValue,
+		ClusterLabel:          name,
+	})
+	return obj
+}
@@ -0,0 +1,175 @@
+package clusters
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority-data: Y2EtZGF0YQ==
+- name: prod
+  cluster:
+    server: https://prod.example.com
+- name: staging
+  cluster:
+    server: https://staging.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+- name: prod
+  context:
+    cluster: prod
+    user: prod
+- name: staging
+  context:
+    cluster: staging
+    user: staging
+users:
+- name: dev
+  user:
+    token: dev-token
+- name: prod
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: ["eks", "get-token"]
+      env:
+      - name: AWS_PROFILE
+        value: prod
+- name: staging
+  user:
+    auth-provider:
+      name: oidc
+      config:
+        idp-issuer-url: https://login.example.com
+`
+
+func TestMinify(t *testing.T) {
+	dat, err := Minify([]byte(testKubeconfig), "dev", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := core.ContextNames(dat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "dev" {
+		t.Fatalf("expected only the dev context, got: %v", names)
+	}
+
+	dat, err = Minify([]byte(testKubeconfig), "", "https://dev.internal:6443")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := core.RESTConfigFromBytes(dat, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.Host != "https://dev.internal:6443" {
+		t.Fatalf("expected server override, got: %s", rc.Host)
+	}
+	if rc.BearerToken != "dev-token" {
+		t.Fatalf("expected dev credentials, got: %s", rc.BearerToken)
+	}
+
+	if _, err := Minify([]byte(testKubeconfig), "missing", ""); err == nil {
+		t.Fatal("expected error for unknown context")
+	}
+}
+
+func TestArgoCDSecret(t *testing.T) {
+	dat, err := Minify([]byte(testKubeconfig), "dev", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := ArgoCDSecret("dev", "argocd", dat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if obj.GetName() != "cluster-dev" || obj.GetNamespace() != "argocd" {
+		t.Fatalf("unexpected secret: %s/%s", obj.GetNamespace(), obj.GetName())
+	}
+	if obj.GetLabels()[argoSecretTypeLabel] != "cluster" {
+		t.Fatalf("expected argocd secret-type label, got: %v", obj.GetLabels())
+	}
+
+	if got := decodeField(t, obj, "server"); got != "https://127.0.0.1:6443" {
+		t.Fatalf("unexpected server: %s", got)
+	}
+
+	cfg := argoClusterConfig{}
+	if err := json.Unmarshal([]byte(decodeField(t, obj, "config")), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BearerToken != "dev-token" {
+		t.Fatalf("unexpected bearer token: %s", cfg.BearerToken)
+	}
+	if string(cfg.TLSClientConfig.CAData) != "ca-data" {
+		t.Fatalf("unexpected CA data: %s", cfg.TLSClientConfig.CAData)
+	}
+}
+
+func TestUnsupportedAuth(t *testing.T) {
+	for _, name := range []string{"prod", "staging"} {
+		if _, err := Minify([]byte(testKubeconfig), name, ""); !errors.Is(err, ErrUnsupportedAuth) {
+			t.Fatalf("%s: expected unsupported auth error, got: %v", name, err)
+		}
+
+		kubeconfig := strings.Replace(testKubeconfig, "current-context: dev", "current-context: "+name, 1)
+		if _, err := ArgoCDSecret(name, "argocd", []byte(kubeconfig)); !errors.Is(err, ErrUnsupportedAuth) {
+			t.Fatalf("%s: expected unsupported auth error building the argocd secret, got: %v", name, err)
+		}
+	}
+}
+
+func TestProviderConfigs(t *testing.T) {
+	all := ProviderConfigs("dev", "landscape-system")
+	if len(all) != 2 {
+		t.Fatalf("expected 2 provider configs, got: %d", len(all))
+	}
+
+	for _, el := range all {
+		if el.GetName() != "dev" || el.GetLabels()[ClusterLabel] != "dev" {
+			t.Fatalf("unexpected provider config: %s %v", el.GetName(), el.GetLabels())
+		}
+
+		ref, _, _ := unstructured.NestedStringMap(el.Object, "spec", "credentials", "secretRef")
+		if ref["name"] != "dev-kubeconfig" || ref["namespace"] != "landscape-system" || ref["key"] != KubeconfigKey {
+			t.Fatalf("unexpected secret ref: %v", ref)
+		}
+	}
+
+	if all[0].GetAPIVersion() != "kubernetes.crossplane.io/v1alpha1" || all[1].GetAPIVersion() != "helm.crossplane.io/v1alpha1" {
+		t.Fatalf("unexpected api versions: %s, %s", all[0].GetAPIVersion(), all[1].GetAPIVersion())
+	}
+}
+
+func decodeField(t *testing.T, obj *unstructured.Unstructured, key string) string {
+	t.Helper()
+
+	enc, _, _ := unstructured.NestedString(obj.Object, "data", key)
+	dat, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(dat)
+}
@@ -34,9 +34,24 @@ type Config struct {
 		Values     map[string]interface{} `mapstructure:"values"`
 	} `mapstructure:"crossplane_chart"`
 
+	// Registry settings
+	ImageRegistries    []string `mapstructure:"image_registries"` // from=to mappings
+	PackagePullSecrets []string `mapstructure:"package_pull_secrets"`
+
 	// Default selections
 	DefaultProviders []string `mapstructure:"default_providers"`
 	DefaultPackages  []string `mapstructure:"default_packages"`
+
+	// Notification settings
+	Webhooks []Webhook `mapstructure:"webhooks"`
+}
+
+// Webhook receives the init and uninstall progress.
+type Webhook struct {
+	URL     string            `mapstructure:"url"`
+	Format  string            `mapstructure:"format"` // json, slack or teams
+	Headers map[string]string `mapstructure:"headers"`
+	Retries int               `mapstructure:"retries"`
 }
 
 func LoadConfig() (*Config, error) {
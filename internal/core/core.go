@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"sort"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -47,6 +48,22 @@ func RESTConfigFromBytes(data []byte, withContext string) (*rest.Config, error)
 	return restConfig, nil
 }
 
+// ContextNames returns the sorted names of the contexts defined in the kubeconfig.
+func ContextNames(data []byte) ([]string, error) {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
+
 // FindGVR find the corresponding GVR (available in *meta.RESTMapping) for gvk
 func FindGVR(cfg *rest.Config, gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
 	// DiscoveryClient queries API server about the resources
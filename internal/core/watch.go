@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/platfornow/lash/internal/wait"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -19,7 +20,7 @@ import (
 )
 
 var (
-	ErrWatcherTimeout = errors.New("watcher timed out")
+	ErrWatcherTimeout = fmt.Errorf("watcher %w", wait.ErrTimeout)
 )
 
 type StopFunc func(et watch.EventType, obj *unstructured.Unstructured) (bool, error)
@@ -33,7 +34,16 @@ type WatchOpts struct {
 	StopFn     StopFunc
 }
 
+// Watch calls opts.StopFn for every event until it returns true, the timeout
+// expires (ErrWatcherTimeout) or ctx is cancelled (the context error).
 func Watch(ctx context.Context, opts WatchOpts) error {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
 	watchFn := func(_ metav1.ListOptions) (watch.Interface, error) {
 		dc, err := dynamic.NewForConfig(opts.RESTConfig)
 		if err != nil {
@@ -69,14 +79,10 @@ func Watch(ctx context.Context, opts WatchOpts) error {
 	}()
 
 	// process incoming event notifications
-	if opts.Timeout <= 0 {
-		opts.Timeout = 3 * time.Minute
-	}
-	timer := time.NewTimer(opts.Timeout)
-	return watchOnce(rw, opts.StopFn, timer)
+	return watchOnce(ctx, rw, opts.StopFn)
 }
 
-func watchOnce(w watch.Interface, stopFn StopFunc, timer *time.Timer) error {
+func watchOnce(ctx context.Context, w watch.Interface, stopFn StopFunc) error {
 	var err error
 
 loop:
@@ -106,8 +112,11 @@ loop:
 			if exit {
 				return nil
 			}
-		case <-timer.C:
-			return ErrWatcherTimeout
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ErrWatcherTimeout
+			}
+			return ctx.Err()
 		}
 	}
 
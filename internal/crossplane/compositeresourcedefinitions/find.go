@@ -0,0 +1,80 @@
+package compositeresourcedefinitions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	xpextv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+)
+
+// ListTyped returns the installed composite resource definitions sorted by name.
+func ListTyped(ctx context.Context, rc *rest.Config) ([]xpextv1.CompositeResourceDefinition, error) {
+	all, err := List(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]xpextv1.CompositeResourceDefinition, 0, len(all))
+	for _, el := range all {
+		var xrd xpextv1.CompositeResourceDefinition
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(el.UnstructuredContent(), &xrd)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, xrd)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].GetName() < res[j].GetName()
+	})
+
+	return res, nil
+}
+
+// Find returns the installed composite resource definition matching name
+// (see Matches); nil if none matches.
+func Find(ctx context.Context, rc *rest.Config, name string) (*xpextv1.CompositeResourceDefinition, error) {
+	all, err := ListTyped(ctx, rc)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *xpextv1.CompositeResourceDefinition
+	for i := range all {
+		if !Matches(&all[i], name) {
+			continue
+		}
+		if res != nil {
+			return nil, fmt.Errorf("'%s' matches both '%s' and '%s', use the full name", name, res.GetName(), all[i].GetName())
+		}
+		res = &all[i]
+	}
+
+	return res, nil
+}
+
+// Matches returns true if name is the definition name (i.e. gitops.pkg.platformnow.io)
+// or, case insensitive, the kind, plural or singular name of its composite
+// resource or claim.
+func Matches(xrd *xpextv1.CompositeResourceDefinition, name string) bool {
+	if xrd.GetName() == name {
+		return true
+	}
+
+	names := []string{xrd.Spec.Names.Kind, xrd.Spec.Names.Plural, xrd.Spec.Names.Singular}
+	if xrd.Spec.ClaimNames != nil {
+		names = append(names, xrd.Spec.ClaimNames.Kind, xrd.Spec.ClaimNames.Plural, xrd.Spec.ClaimNames.Singular)
+	}
+
+	for _, el := range names {
+		if len(el) > 0 && strings.EqualFold(el, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package compositeresourcedefinitions
+
+import (
+	"testing"
+
+	xpextv1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestMatches(t *testing.T) {
+	xrd := &xpextv1.CompositeResourceDefinition{}
+	xrd.SetName("xobservabilities.pkg.platformnow.io")
+	xrd.Spec.Names = extv1.CustomResourceDefinitionNames{
+		Kind:     "XObservability",
+		Plural:   "xobservabilities",
+		Singular: "xobservability",
+	}
+	xrd.Spec.ClaimNames = &extv1.CustomResourceDefinitionNames{
+		Kind:     "Observability",
+		Plural:   "observabilities",
+		Singular: "observability",
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"xobservabilities.pkg.platformnow.io", true},
+		{"observability", true},
+		{"Observability", true},
+		{"xobservabilities", true},
+		{"observabilities.pkg.platformnow.io", false},
+		{"gitops", false},
+		{"", false},
+	}
+
+	for _, tc := range tests {
+		if got := Matches(xrd, tc.name); got != tc.want {
+			t.Errorf("Matches(%q): expected %t, got %t", tc.name, tc.want, got)
+		}
+	}
+}
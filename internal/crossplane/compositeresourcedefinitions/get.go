@@ -11,7 +11,7 @@ import (
 )
 
 func Get(ctx context.Context, rc *rest.Config, name string) (*xpextv1.CompositeResourceDefinition, error) {
-	obj, err := core.Get(context.TODO(), core.GetOpts{
+	obj, err := core.Get(ctx, core.GetOpts{
 		RESTConfig: rc,
 		GVK: schema.GroupVersionKind{
 			Group:   "apiextensions.crossplane.io",
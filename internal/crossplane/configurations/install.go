@@ -2,21 +2,41 @@ package configurations
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/platfornow/lash/internal/catalog"
 	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane"
+	"github.com/platfornow/lash/internal/diagnostics"
 	"github.com/platfornow/lash/internal/eventbus"
 	"github.com/platfornow/lash/internal/events"
+	"github.com/platfornow/lash/internal/oci"
+	"github.com/platfornow/lash/internal/wait"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
+	"time"
 )
 
 type InstallOpts struct {
 	RESTConfig *rest.Config
 	Info       *catalog.PackageInfo
+	Catalog    catalog.FetchOpts
 	Namespace  string
 	EventBus   eventbus.Bus
 	Verbose    bool
+	// PackageFn, when set, rewrites the configuration spec.package before
+	// it is applied (i.e. to pin the image digest).
+	PackageFn func(ctx context.Context, pkg string) (string, error)
+	// ImageRegistries moves spec.package to a private registry mirror.
+	ImageRegistries oci.Mirrors
+	// PullSecrets are added to spec.packagePullSecrets.
+	PullSecrets []string
+	// Timeout is how long to wait for the configuration to be healthy
+	// (wait.Defaults when not set).
+	Timeout time.Duration
+	// DiagnosticsDir, if set, is where the diagnostics of a failed wait
+	// are saved.
+	DiagnosticsDir string
 }
 
 type Yaml struct {
@@ -26,7 +46,7 @@ type Yaml struct {
 
 func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 
-	data, err := catalog.FetchManifest(opts.Info)
+	data, err := catalog.FetchManifest(opts.Info, opts.Catalog)
 	if err != nil {
 		return err
 	}
@@ -49,6 +69,10 @@ func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 		core.InstalledByLabel: core.InstalledByValue,
 	})
 
+	if err := crossplane.PreparePackage(ctx, obj, opts.ImageRegistries, opts.PackageFn, opts.PullSecrets); err != nil {
+		return err
+	}
+
 	// install the object
 	opts.EventBus.Publish(events.NewStartWaitEvent("Installing %s %s", opts.Info.Name, opts.Info.Manifest))
 	err = core.Apply(ctx, core.ApplyOpts{RESTConfig: opts.RESTConfig, Object: obj, GVK: *gvk})
@@ -56,10 +80,27 @@ func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 		return err
 	}
 
-	opts.EventBus.Publish(events.NewDoneEvent("Installed %s %s", opts.Info.Name, opts.Info.Manifest))
+	opts.EventBus.Publish(events.NewObjectApplied(obj, events.ActionApplied))
 
 	// wait for it
-	return WaitUntilHealtyAndInstalled(ctx, opts.RESTConfig, obj.GetName())
+	timeout := wait.Timeout(opts.Timeout, wait.PhaseConfigurations)
+	opts.EventBus.Publish(&events.WaitProgress{
+		Phase:   wait.PhaseConfigurations,
+		Object:  events.ObjectOf(obj),
+		Timeout: timeout,
+	})
+
+	err = wait.For(ctx, fmt.Sprintf("configuration %s to be healthy", obj.GetName()), timeout, func(ctx context.Context) error {
+		return WaitUntilHealtyAndInstalled(ctx, opts.RESTConfig, obj.GetName(), timeout)
+	})
+	if errors.Is(err, wait.ErrTimeout) {
+		diagnostics.Publish(ctx, opts.EventBus, "configuration "+obj.GetName(), diagnostics.Opts{
+			RESTConfig: opts.RESTConfig,
+			Objects:    []diagnostics.Object{{Kind: "Configuration", Name: obj.GetName()}},
+		}, diagnostics.Dir(opts.DiagnosticsDir, "configuration-"+obj.GetName()))
+	}
+
+	return err
 }
 
 func isCrossplaneProvider(gvk *schema.GroupVersionKind) bool {
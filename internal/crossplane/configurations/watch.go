@@ -56,7 +56,9 @@ type ConditionedStatus struct {
 	Conditions []Condition `json:"conditions,omitempty"`
 }
 
-func WaitUntilHealtyAndInstalled(ctx context.Context, restConfig *rest.Config, name string) error {
+// WaitUntilHealtyAndInstalled waits up to timeout for the named configuration
+// to be Healthy and Installed.
+func WaitUntilHealtyAndInstalled(ctx context.Context, restConfig *rest.Config, name string, timeout time.Duration) error {
 	stopFn := func(et watch.EventType, obj *unstructured.Unstructured) (bool, error) {
 		if obj.GetName() != name {
 			return false, nil
@@ -99,6 +101,6 @@ func WaitUntilHealtyAndInstalled(ctx context.Context, restConfig *rest.Config, n
 			Resource: "configurations",
 		},
 		StopFn:  stopFn,
-		Timeout: time.Minute * 2,
+		Timeout: timeout,
 	})
 }
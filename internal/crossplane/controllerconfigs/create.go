@@ -35,36 +35,13 @@ type CreateOpts struct {
 	HttpProxy  string
 	HttpsProxy string
 	NoProxy    string
+	// CrossplaneVersion selects the runtime config kind: DeploymentRuntimeConfig
+	// from Crossplane v1.14, ControllerConfig before.
+	CrossplaneVersion string
 }
 
 func Create(ctx context.Context, opts CreateOpts) (*unstructured.Unstructured, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "pkg.crossplane.io",
-		Version:  "v1alpha1",
-		Resource: "controllerconfigs",
-	}
-
-	envVars := []interface{}{}
-	if opts.HttpProxy != "" {
-		envVars = append(envVars, map[string]string{
-			"name":  "HTTP_PROXY",
-			"value": opts.HttpProxy,
-		})
-	}
-
-	if opts.HttpsProxy != "" {
-		envVars = append(envVars, map[string]string{
-			"name":  "HTTPS_PROXY",
-			"value": opts.HttpsProxy,
-		})
-	}
-
-	if opts.NoProxy != "" {
-		envVars = append(envVars, map[string]string{
-			"name":  "NO_PROXY",
-			"value": opts.NoProxy,
-		})
-	}
+	envVars := ProxyEnv(opts.HttpProxy, opts.HttpsProxy, opts.NoProxy)
 
 	obj := &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -82,13 +59,32 @@ func Create(ctx context.Context, opts CreateOpts) (*unstructured.Unstructured, e
 		},
 	}
 
-	obj.SetKind("ControllerConfig")
-	obj.SetAPIVersion("pkg.crossplane.io/v1alpha1")
+	obj.SetGroupVersionKind(ControllerConfigGVK)
 	obj.SetName(fmt.Sprintf("%s-controllerconfig", opts.Info.Name))
 	obj.SetLabels(map[string]string{
 		core.InstalledByLabel: core.InstalledByValue,
 	})
 
+	gvr := schema.GroupVersionResource{
+		Group:    ControllerConfigGVK.Group,
+		Version:  ControllerConfigGVK.Version,
+		Resource: "controllerconfigs",
+	}
+
+	if UseDeploymentRuntimeConfig(opts.CrossplaneVersion) {
+		var err error
+		obj, err = ToDeploymentRuntimeConfig(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		gvr = schema.GroupVersionResource{
+			Group:    DeploymentRuntimeConfigGVK.Group,
+			Version:  DeploymentRuntimeConfigGVK.Version,
+			Resource: "deploymentruntimeconfigs",
+		}
+	}
+
 	// prepare the dynamic client
 	dc, err := dynamic.NewForConfig(opts.RESTConfig)
 	if err != nil {
@@ -104,3 +100,56 @@ func Create(ctx context.Context, opts CreateOpts) (*unstructured.Unstructured, e
 
 	return obj, nil
 }
+
+// ProxyEnv returns the proxy environment variables of the provider
+// container, skipping the empty ones.
+func ProxyEnv(httpProxy, httpsProxy, noProxy string) []interface{} {
+	res := []interface{}{}
+	for _, el := range []struct{ name, value string }{
+		{"HTTP_PROXY", httpProxy},
+		{"HTTPS_PROXY", httpsProxy},
+		{"NO_PROXY", noProxy},
+	} {
+		if el.value != "" {
+			res = append(res, map[string]interface{}{
+				"name":  el.name,
+				"value": el.value,
+			})
+		}
+	}
+
+	return res
+}
+
+// MergeEnv sets the env vars in the ControllerConfig spec.env, replacing
+// the variables with the same name.
+func MergeEnv(obj *unstructured.Unstructured, env []interface{}) error {
+	if len(env) == 0 {
+		return nil
+	}
+
+	cur, _, err := unstructured.NestedSlice(obj.Object, "spec", "env")
+	if err != nil {
+		return err
+	}
+
+	res := make([]interface{}, 0, len(cur)+len(env))
+	for _, el := range cur {
+		if m, ok := el.(map[string]interface{}); ok && hasEnvVar(env, m["name"]) {
+			continue
+		}
+		res = append(res, el)
+	}
+	res = append(res, env...)
+
+	return unstructured.SetNestedSlice(obj.Object, res, "spec", "env")
+}
+
+func hasEnvVar(env []interface{}, name interface{}) bool {
+	for _, el := range env {
+		if m, ok := el.(map[string]interface{}); ok && m["name"] == name {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,8 @@ import (
 type DeleteOpts struct {
 	RESTConfig *rest.Config
 	Name       string
+	// GVK of the runtime config, ControllerConfig if not set.
+	GVK schema.GroupVersionKind
 }
 
 func Delete(ctx context.Context, opts DeleteOpts) (err error) {
@@ -22,6 +24,14 @@ func Delete(ctx context.Context, opts DeleteOpts) (err error) {
 		Resource: "controllerconfigs",
 	}
 
+	if opts.GVK == DeploymentRuntimeConfigGVK {
+		gvr = schema.GroupVersionResource{
+			Group:    DeploymentRuntimeConfigGVK.Group,
+			Version:  DeploymentRuntimeConfigGVK.Version,
+			Resource: "deploymentruntimeconfigs",
+		}
+	}
+
 	dc, err := dynamic.NewForConfig(opts.RESTConfig)
 	if err != nil {
 		return err
@@ -9,13 +9,29 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// ListAll returns both the ControllerConfigs and the DeploymentRuntimeConfigs
+// installed by lash, so that the Crossplane 'default' DeploymentRuntimeConfig
+// is left alone; kinds not served by the cluster are skipped.
 func ListAll(ctx context.Context, restConfig *rest.Config) ([]unstructured.Unstructured, error) {
-	return core.List(ctx, core.ListOpts{
-		RESTConfig: restConfig,
-		GVK: schema.GroupVersionKind{
-			Group:   "pkg.crossplane.io",
-			Version: "v1alpha1",
-			Kind:    "ControllerConfig",
-		},
-	})
+	sel, err := core.InstalledBySelector()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []unstructured.Unstructured{}
+
+	for _, gvk := range []schema.GroupVersionKind{ControllerConfigGVK, DeploymentRuntimeConfigGVK} {
+		all, err := core.List(ctx, core.ListOpts{
+			RESTConfig:    restConfig,
+			GVK:           gvk,
+			LabelSelector: sel.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, all...)
+	}
+
+	return res, nil
 }
@@ -42,3 +42,18 @@ func TestList(t *testing.T) {
 		t.Logf("> %s\n", el.GetName())
 	}
 }
+
+func TestListAll(t *testing.T) {
+	kubeconfig, err := ioutil.ReadFile(clientcmd.RecommendedHomeFile)
+	assert.Nil(t, err, "expecting nil error loading kubeconfig")
+
+	restConfig, err := core.RESTConfigFromBytes(kubeconfig, "")
+	assert.Nil(t, err, "expecting nil error creating rest.Config")
+
+	list, err := ListAll(context.TODO(), restConfig)
+	assert.Nil(t, err, "expecting nil error listing runtime configs")
+
+	for _, el := range list {
+		assert.Equal(t, core.InstalledByValue, el.GetLabels()[core.InstalledByLabel], "expecting only runtime configs installed by lash")
+	}
+}
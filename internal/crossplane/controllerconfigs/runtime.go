@@ -0,0 +1,139 @@
+package controllerconfigs
+
+import (
+	"github.com/Masterminds/semver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// runtimeConfigMinVersion is the first Crossplane version deprecating
+	// ControllerConfig in favor of DeploymentRuntimeConfig.
+	runtimeConfigMinVersion = "1.14.0"
+
+	// runtimeContainerName is the provider container in the deployment
+	// Crossplane generates for the package.
+	runtimeContainerName = "package-runtime"
+)
+
+var (
+	ControllerConfigGVK = schema.GroupVersionKind{
+		Group:   "pkg.crossplane.io",
+		Version: "v1alpha1",
+		Kind:    "ControllerConfig",
+	}
+
+	DeploymentRuntimeConfigGVK = schema.GroupVersionKind{
+		Group:   "pkg.crossplane.io",
+		Version: "v1beta1",
+		Kind:    "DeploymentRuntimeConfig",
+	}
+)
+
+// UseDeploymentRuntimeConfig returns true when the Crossplane version
+// (i.e. the image tag of the Crossplane pod) supports DeploymentRuntimeConfig;
+// unknown versions are handled as older ones.
+func UseDeploymentRuntimeConfig(crossplaneVersion string) bool {
+	ver, err := semver.NewVersion(crossplaneVersion)
+	if err != nil {
+		return false
+	}
+
+	min, _ := semver.NewVersion(runtimeConfigMinVersion)
+	return !ver.LessThan(min)
+}
+
+// GVK returns the runtime config kind to use with the Crossplane version.
+func GVK(crossplaneVersion string) schema.GroupVersionKind {
+	if UseDeploymentRuntimeConfig(crossplaneVersion) {
+		return DeploymentRuntimeConfigGVK
+	}
+	return ControllerConfigGVK
+}
+
+// ToDeploymentRuntimeConfig converts a ControllerConfig into the equivalent
+// DeploymentRuntimeConfig: pod metadata, env, args, image and scheduling
+// settings are moved into the deployment and service account templates.
+func ToDeploymentRuntimeConfig(cc *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	spec, _, err := unstructured.NestedMap(cc.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+
+	container := map[string]interface{}{
+		"name": runtimeContainerName,
+	}
+	for _, key := range []string{"env", "envFrom", "args", "image", "imagePullPolicy", "resources", "volumeMounts"} {
+		if val, ok := spec[key]; ok {
+			container[key] = val
+		}
+	}
+	if val, ok := spec["securityContext"]; ok {
+		container["securityContext"] = val
+	}
+
+	podSpec := map[string]interface{}{
+		"containers": []interface{}{container},
+	}
+	for _, key := range []string{"nodeSelector", "tolerations", "affinity", "priorityClassName", "runtimeClassName", "volumes", "imagePullSecrets"} {
+		if val, ok := spec[key]; ok {
+			podSpec[key] = val
+		}
+	}
+	if val, ok := spec["podSecurityContext"]; ok {
+		podSpec["securityContext"] = val
+	}
+
+	template := map[string]interface{}{
+		"spec": podSpec,
+	}
+	if val, ok := spec["metadata"]; ok {
+		template["metadata"] = val
+	}
+
+	deploymentSpec := map[string]interface{}{
+		"selector": map[string]interface{}{},
+		"template": template,
+	}
+	if val, ok := spec["replicas"]; ok {
+		deploymentSpec["replicas"] = val
+	}
+
+	drcSpec := map[string]interface{}{
+		"deploymentTemplate": map[string]interface{}{
+			"spec": deploymentSpec,
+		},
+	}
+	if val, ok := spec["serviceAccountName"]; ok {
+		drcSpec["serviceAccountTemplate"] = map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": val,
+			},
+		}
+	}
+
+	res := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": drcSpec,
+		},
+	}
+	res.SetGroupVersionKind(DeploymentRuntimeConfigGVK)
+	res.SetName(cc.GetName())
+	res.SetLabels(cc.GetLabels())
+	res.SetAnnotations(cc.GetAnnotations())
+
+	return res, nil
+}
+
+// UseRuntimeConfigRef moves the package spec.controllerConfigRef to
+// spec.runtimeConfigRef, referencing a DeploymentRuntimeConfig.
+func UseRuntimeConfigRef(pkg *unstructured.Unstructured) error {
+	ref, ok, err := unstructured.NestedMap(pkg.Object, "spec", "controllerConfigRef")
+	if err != nil || !ok {
+		return err
+	}
+
+	unstructured.RemoveNestedField(pkg.Object, "spec", "controllerConfigRef")
+
+	return unstructured.SetNestedMap(pkg.Object, ref, "spec", "runtimeConfigRef")
+}
@@ -0,0 +1,148 @@
+package controllerconfigs
+
+import (
+	"testing"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUseDeploymentRuntimeConfig(t *testing.T) {
+	table := []struct {
+		ver  string
+		want bool
+	}{
+		{"v1.13.2", false},
+		{"v1.14.0", true},
+		{"v1.15.1-up.1", true},
+		{"1.20.0", true},
+		{"", false},
+		{"latest", false},
+	}
+
+	for _, tc := range table {
+		if got := UseDeploymentRuntimeConfig(tc.ver); got != tc.want {
+			t.Fatalf("%q: expected: %v, got: %v", tc.ver, tc.want, got)
+		}
+	}
+}
+
+func TestToDeploymentRuntimeConfig(t *testing.T) {
+	cc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						core.PackageNameLabel: "provider-helm",
+					},
+				},
+				"serviceAccountName": "provider-helm",
+				"env": []interface{}{
+					map[string]interface{}{"name": "HTTPS_PROXY", "value": "http://proxy:3128"},
+				},
+				"args": []interface{}{"--debug"},
+			},
+		},
+	}
+	cc.SetGroupVersionKind(ControllerConfigGVK)
+	cc.SetName("provider-helm")
+
+	drc, err := ToDeploymentRuntimeConfig(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if drc.GroupVersionKind() != DeploymentRuntimeConfigGVK {
+		t.Fatalf("unexpected kind: %s", drc.GroupVersionKind())
+	}
+
+	lbl, _, _ := unstructured.NestedString(drc.Object, "spec", "deploymentTemplate", "spec", "template", "metadata", "labels", core.PackageNameLabel)
+	if lbl != "provider-helm" {
+		t.Fatalf("expected pod label, got: %q", lbl)
+	}
+
+	sa, _, _ := unstructured.NestedString(drc.Object, "spec", "serviceAccountTemplate", "metadata", "name")
+	if sa != "provider-helm" {
+		t.Fatalf("expected service account name, got: %q", sa)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(drc.Object, "spec", "deploymentTemplate", "spec", "template", "spec", "containers")
+	if len(containers) != 1 {
+		t.Fatalf("expected one container, got: %d", len(containers))
+	}
+
+	container := containers[0].(map[string]interface{})
+	if container["name"] != runtimeContainerName {
+		t.Fatalf("unexpected container name: %v", container["name"])
+	}
+	if env := container["env"].([]interface{}); len(env) != 1 {
+		t.Fatalf("expected proxy env var, got: %v", env)
+	}
+}
+
+func TestUseRuntimeConfigRef(t *testing.T) {
+	pkg := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"package": "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0",
+				"controllerConfigRef": map[string]interface{}{
+					"name": "provider-helm",
+				},
+			},
+		},
+	}
+
+	if err := UseRuntimeConfigRef(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := unstructured.NestedMap(pkg.Object, "spec", "controllerConfigRef"); ok {
+		t.Fatal("expected controllerConfigRef to be removed")
+	}
+
+	name, _, _ := unstructured.NestedString(pkg.Object, "spec", "runtimeConfigRef", "name")
+	if name != "provider-helm" {
+		t.Fatalf("expected runtimeConfigRef name provider-helm, got: %q", name)
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	cc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"env": []interface{}{
+					map[string]interface{}{"name": "HTTPS_PROXY", "value": "http://old:3128"},
+					map[string]interface{}{"name": "DEBUG", "value": "true"},
+				},
+			},
+		},
+	}
+
+	err := MergeEnv(cc, ProxyEnv("", "http://proxy:3128", "10.0.0.0/8,.svc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env, _, _ := unstructured.NestedSlice(cc.Object, "spec", "env")
+
+	got := map[string]string{}
+	for _, el := range env {
+		m := el.(map[string]interface{})
+		got[m["name"].(string)] = m["value"].(string)
+	}
+
+	want := map[string]string{
+		"DEBUG":       "true",
+		"HTTPS_PROXY": "http://proxy:3128",
+		"NO_PROXY":    "10.0.0.0/8,.svc",
+	}
+
+	if len(got) != len(want) || len(env) != len(want) {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s: expected: %s, got: %s", k, v, got[k])
+		}
+	}
+}
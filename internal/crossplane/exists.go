@@ -24,6 +24,17 @@ func PODImageVersion(pod *corev1.Pod) (string, error) {
 	return "", nil
 }
 
+// InstalledVersion returns the version of the running Crossplane,
+// empty if Crossplane is not installed.
+func InstalledVersion(ctx context.Context, restConfig *rest.Config) (string, error) {
+	pod, err := InstalledPOD(ctx, restConfig)
+	if err != nil || pod == nil {
+		return "", err
+	}
+
+	return PODImageVersion(pod)
+}
+
 func InstalledPOD(ctx context.Context, restConfig *rest.Config) (*corev1.Pod, error) {
 	items, err := core.List(ctx, core.ListOpts{
 		RESTConfig:    restConfig,
@@ -3,14 +3,18 @@ package crossplane
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/diagnostics"
 	"github.com/platfornow/lash/internal/eventbus"
 	"github.com/platfornow/lash/internal/events"
 	"github.com/platfornow/lash/internal/helm"
 	"github.com/platfornow/lash/internal/httputils"
 	"github.com/platfornow/lash/internal/pods"
+	"github.com/platfornow/lash/internal/wait"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -33,6 +37,12 @@ type InstallOpts struct {
 	NoProxy    string
 	Namespace  string
 	EventBus   eventbus.Bus
+	// Timeout is how long to wait for the Crossplane pods readiness
+	// (wait.Defaults when not set).
+	Timeout time.Duration
+	// DiagnosticsDir, if set, is where the diagnostics of a failed wait
+	// are saved.
+	DiagnosticsDir string
 }
 
 func Install(ctx context.Context, opts InstallOpts) error {
@@ -88,12 +98,36 @@ func Install(ctx context.Context, opts InstallOpts) error {
 		envVars["NO_PROXY"] = opts.NoProxy
 	}
 
-	err = helm.Install(helmOpts)
+	err = helm.Install(ctx, helmOpts)
 	if err != nil {
 		return err
 	}
 
-	return waitUntilCrossplaneIdReady(opts.RESTConfig, opts.Namespace)
+	timeout := wait.Timeout(opts.Timeout, wait.PhaseCrossplane)
+	if opts.EventBus != nil {
+		opts.EventBus.Publish(&events.WaitProgress{
+			Phase: wait.PhaseCrossplane,
+			Object: &events.Object{
+				GVK:       schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				Name:      "crossplane",
+				Namespace: opts.Namespace,
+			},
+			Timeout: timeout,
+		})
+	}
+
+	err = wait.For(ctx, "Crossplane pods readiness", timeout, func(ctx context.Context) error {
+		return waitUntilCrossplaneIdReady(ctx, opts.RESTConfig, opts.Namespace)
+	})
+	if errors.Is(err, wait.ErrTimeout) && opts.EventBus != nil {
+		diagnostics.Publish(ctx, opts.EventBus, "Crossplane", diagnostics.Opts{
+			RESTConfig: opts.RESTConfig,
+			Namespace:  opts.Namespace,
+			Selector:   labels.SelectorFromSet(labels.Set{"app": "crossplane"}),
+		}, diagnostics.Dir(opts.DiagnosticsDir, "crossplane"))
+	}
+
+	return err
 }
 
 func createNamespaceEventually(ctx context.Context, restConfig *rest.Config, namespace string) error {
@@ -117,7 +151,7 @@ func createNamespaceEventually(ctx context.Context, restConfig *rest.Config, nam
 }
 
 // waitUntilCrossplaneIdReady waits until Crossplane PODs are ready
-func waitUntilCrossplaneIdReady(restConfig *rest.Config, namespace string) error {
+func waitUntilCrossplaneIdReady(ctx context.Context, restConfig *rest.Config, namespace string) error {
 	sel, err := labels.Parse("app=crossplane")
 	if err != nil {
 		return err
@@ -133,7 +167,7 @@ func waitUntilCrossplaneIdReady(restConfig *rest.Config, namespace string) error
 		return err
 	}
 
-	return pods.Watch(dc, pods.WatchOpts{
+	return pods.Watch(ctx, dc, pods.WatchOpts{
 		Namespace: namespace,
 		Selector:  sel,
 		StopFunc:  stopFn,
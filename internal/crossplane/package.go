@@ -0,0 +1,61 @@
+package crossplane
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/platfornow/lash/internal/oci"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PreparePackage moves the spec.package of a provider or configuration to
+// the registry mirror, applies fn (when set, i.e. to pin the image digest)
+// and adds the pull secrets to spec.packagePullSecrets.
+func PreparePackage(ctx context.Context, obj *unstructured.Unstructured, mirrors oci.Mirrors, fn func(ctx context.Context, pkg string) (string, error), pullSecrets []string) error {
+	pkg, _, err := unstructured.NestedString(obj.Object, "spec", "package")
+	if err != nil {
+		return err
+	}
+
+	pkg, err = mirrors.Rewrite(pkg)
+	if err != nil {
+		return err
+	}
+
+	if fn != nil {
+		pkg, err = fn(ctx, pkg)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = unstructured.SetNestedField(obj.Object, pkg, "spec", "package")
+	if err != nil {
+		return err
+	}
+
+	if len(pullSecrets) == 0 {
+		return nil
+	}
+
+	secrets, _, err := unstructured.NestedSlice(obj.Object, "spec", "packagePullSecrets")
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for _, el := range secrets {
+		if m, ok := el.(map[string]interface{}); ok {
+			names[fmt.Sprint(m["name"])] = true
+		}
+	}
+
+	for _, name := range pullSecrets {
+		if !names[name] {
+			secrets = append(secrets, map[string]interface{}{"name": name})
+			names[name] = true
+		}
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, secrets, "spec", "packagePullSecrets")
+}
@@ -0,0 +1,49 @@
+package crossplane
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/platfornow/lash/internal/oci"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPreparePackage(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "pkg.crossplane.io/v1",
+		"kind":       "Provider",
+		"spec": map[string]interface{}{
+			"package":            "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0",
+			"packagePullSecrets": []interface{}{map[string]interface{}{"name": "regcred"}},
+		},
+	}}
+
+	mirrors, err := oci.ParseMirrors([]string{"xpkg.upbound.io=registry.corp/upbound"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pin := func(_ context.Context, pkg string) (string, error) {
+		return pkg + "@sha256:abc", nil
+	}
+
+	err = PreparePackage(context.Background(), obj, mirrors, pin, []string{"regcred", "corp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, _, _ := unstructured.NestedString(obj.Object, "spec", "package")
+	if want := "registry.corp/upbound/crossplane-contrib/provider-helm:v0.15.0@sha256:abc"; pkg != want {
+		t.Fatalf("expected package: %s, got: %s", want, pkg)
+	}
+
+	secrets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "packagePullSecrets")
+	want := []interface{}{
+		map[string]interface{}{"name": "regcred"},
+		map[string]interface{}{"name": "corp"},
+	}
+	if !reflect.DeepEqual(secrets, want) {
+		t.Fatalf("expected pull secrets: %v, got: %v", want, secrets)
+	}
+}
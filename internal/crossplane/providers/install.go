@@ -2,11 +2,18 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/platfornow/lash/internal/catalog"
 	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane"
+	"github.com/platfornow/lash/internal/crossplane/controllerconfigs"
+	"github.com/platfornow/lash/internal/diagnostics"
 	"github.com/platfornow/lash/internal/eventbus"
 	"github.com/platfornow/lash/internal/events"
+	"github.com/platfornow/lash/internal/oci"
+	"github.com/platfornow/lash/internal/rbac"
+	"github.com/platfornow/lash/internal/wait"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -16,20 +23,51 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 	"path"
-	"strings"
+	"time"
+)
+
+// Files published in the catalog next to each provider manifest.
+const (
+	ControllerConfigFile   = "controller-config.yaml"
+	ServiceAccountFile     = "service-account.yaml"
+	ClusterRoleBindingFile = "cluster-role-binding.yaml"
 )
 
 type InstallOpts struct {
 	RESTConfig *rest.Config
 	Info       *catalog.PackageInfo
+	Catalog    catalog.FetchOpts
 	Namespace  string
 	EventBus   eventbus.Bus
 	Verbose    bool
+	// PackageFn, when set, rewrites the provider spec.package before
+	// it is applied (i.e. to pin the image digest).
+	PackageFn func(ctx context.Context, pkg string) (string, error)
+	// ImageRegistries moves spec.package to a private registry mirror.
+	ImageRegistries oci.Mirrors
+	// PullSecrets are added to spec.packagePullSecrets.
+	PullSecrets []string
+	// CrossplaneVersion selects the runtime config kind: the catalog
+	// ControllerConfig is converted to a DeploymentRuntimeConfig from v1.14.
+	CrossplaneVersion string
+	// RBACMode is rbac.ModeAdmin (the catalog binding is applied as is) or
+	// rbac.ModeMinimal (the binding references the catalog RBAC profile).
+	RBACMode string
+	// Proxy settings added to the provider runtime config env.
+	HttpProxy  string
+	HttpsProxy string
+	NoProxy    string
+	// Timeout is how long to wait for the provider pods readiness
+	// (wait.Defaults when not set).
+	Timeout time.Duration
+	// DiagnosticsDir, if set, is where the diagnostics of a failed wait
+	// are saved.
+	DiagnosticsDir string
 }
 
 type Yaml struct {
 	name string
-	url  string
+	file string
 }
 
 func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
@@ -38,13 +76,14 @@ func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 	*pp = *opts.Info
 
 	var yamls [4]Yaml
-	yamls[0] = Yaml{name: "provider", url: pp.Manifest}
-	yamls[1] = Yaml{name: "controller-config", url: strings.Replace(pp.Manifest, path.Base(pp.Manifest), "controller-config.yaml", -1)}
-	yamls[2] = Yaml{name: "service-account", url: strings.Replace(pp.Manifest, path.Base(pp.Manifest), "service-account.yaml", -1)}
-	yamls[3] = Yaml{name: "cluster-role-binding", url: strings.Replace(pp.Manifest, path.Base(pp.Manifest), "cluster-role-binding.yaml", -1)}
+	yamls[0] = Yaml{name: "provider", file: path.Base(pp.Manifest)}
+	yamls[1] = Yaml{name: "controller-config", file: ControllerConfigFile}
+	yamls[2] = Yaml{name: "service-account", file: ServiceAccountFile}
+	yamls[3] = Yaml{name: "cluster-role-binding", file: ClusterRoleBindingFile}
 
+	providerName := opts.Info.Name
 	for _, yaml := range yamls {
-		yamlData, err := catalog.FetchManifestFromUrl(yaml.url)
+		yamlData, err := catalog.FetchPackageFile(pp, yaml.file, opts.Catalog)
 		if err != nil {
 			return err
 		}
@@ -63,6 +102,19 @@ func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 			return fmt.Errorf("%s is not a provider", obj.GetName())
 		}
 
+		if yaml.name == "provider" {
+			providerName = obj.GetName()
+			if err := crossplane.PreparePackage(ctx, obj, opts.ImageRegistries, opts.PackageFn, opts.PullSecrets); err != nil {
+				return err
+			}
+
+			if controllerconfigs.UseDeploymentRuntimeConfig(opts.CrossplaneVersion) {
+				if err := controllerconfigs.UseRuntimeConfigRef(obj); err != nil {
+					return err
+				}
+			}
+		}
+
 		// update the controller config with labels, so we can watch based on them
 		if yaml.name == "controller-config" {
 
@@ -82,6 +134,25 @@ func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 				core.InstalledByLabel: core.InstalledByValue,
 			})
 
+			err = controllerconfigs.MergeEnv(obj, controllerconfigs.ProxyEnv(opts.HttpProxy, opts.HttpsProxy, opts.NoProxy))
+			if err != nil {
+				return err
+			}
+
+			if controllerconfigs.UseDeploymentRuntimeConfig(opts.CrossplaneVersion) {
+				obj, err = controllerconfigs.ToDeploymentRuntimeConfig(obj)
+				if err != nil {
+					return err
+				}
+				drc := controllerconfigs.DeploymentRuntimeConfigGVK
+				gvk = &drc
+			}
+		}
+
+		if yaml.name == "cluster-role-binding" {
+			if err := prepareBinding(ctx, obj, opts); err != nil {
+				return err
+			}
 		}
 
 		opts.EventBus.Publish(events.NewStartWaitEvent("Installing %s %s", pp.Name, yaml.name))
@@ -90,22 +161,51 @@ func InstallFromRepo(ctx context.Context, opts InstallOpts) error {
 			return err
 		}
 
-		opts.EventBus.Publish(events.NewDoneEvent("Installed %s %s", pp.Name, yaml.name))
+		opts.EventBus.Publish(events.NewObjectApplied(obj, events.ActionApplied))
 	}
 
 	// wait for it
-	return waitUntilProviderIsReady(ctx, opts.RESTConfig, opts.Info.Name, opts.Namespace)
+	timeout := wait.Timeout(opts.Timeout, wait.PhaseProviders)
+	opts.EventBus.Publish(&events.WaitProgress{
+		Phase: wait.PhaseProviders,
+		Object: &events.Object{
+			GVK:  schema.GroupVersionKind{Group: "pkg.crossplane.io", Version: "v1", Kind: "Provider"},
+			Name: providerName,
+		},
+		Timeout: timeout,
+	})
+
+	sel, err := podSelector(opts.Info.Name)
+	if err != nil {
+		return err
+	}
+
+	err = wait.For(ctx, fmt.Sprintf("provider %s readiness", opts.Info.Name), timeout, func(ctx context.Context) error {
+		return waitUntilProviderIsReady(ctx, opts.RESTConfig, sel, opts.Namespace, timeout)
+	})
+	if errors.Is(err, wait.ErrTimeout) {
+		diagnostics.Publish(ctx, opts.EventBus, "provider "+opts.Info.Name, diagnostics.Opts{
+			RESTConfig: opts.RESTConfig,
+			Namespace:  opts.Namespace,
+			Selector:   sel,
+			Objects:    []diagnostics.Object{{Kind: "Provider", Name: providerName}},
+		}, diagnostics.Dir(opts.DiagnosticsDir, "provider-"+opts.Info.Name))
+	}
+
+	return err
 }
 
-func waitUntilProviderIsReady(ctx context.Context, restConfig *rest.Config, name, namespace string) error {
+// podSelector selects the pods of the named provider.
+func podSelector(name string) (labels.Selector, error) {
 	req, err := labels.NewRequirement(core.PackageNameLabel, selection.Equals, []string{name})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sel := labels.NewSelector()
-	sel = sel.Add(*req)
+	return labels.NewSelector().Add(*req), nil
+}
 
+func waitUntilProviderIsReady(ctx context.Context, restConfig *rest.Config, sel labels.Selector, namespace string, timeout time.Duration) error {
 	stopFn := func(et watch.EventType, obj *unstructured.Unstructured) (bool, error) {
 		pod := &corev1.Pod{}
 		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &pod)
@@ -128,9 +228,48 @@ func waitUntilProviderIsReady(ctx context.Context, restConfig *rest.Config, name
 		Namespace:  namespace,
 		Selector:   sel,
 		StopFn:     stopFn,
+		Timeout:    timeout,
 	})
 }
 
+// prepareBinding labels the binding and, in minimal mode, creates the
+// package ClusterRole and binds it in place of the catalog role. A binding
+// installed with the other mode is deleted, its roleRef being immutable.
+func prepareBinding(ctx context.Context, obj *unstructured.Unstructured, opts InstallOpts) error {
+	lbls := obj.GetLabels()
+	if lbls == nil {
+		lbls = map[string]string{}
+	}
+	lbls[core.InstalledByLabel] = core.InstalledByValue
+	lbls[core.PackageNameLabel] = opts.Info.Name
+	obj.SetLabels(lbls)
+
+	if opts.RBACMode == rbac.ModeMinimal {
+		if err := restrictBinding(ctx, obj, opts); err != nil {
+			return err
+		}
+	}
+
+	return rbac.DeleteIfRoleChanged(ctx, opts.RESTConfig, obj)
+}
+
+// restrictBinding creates the package ClusterRole and binds it.
+func restrictBinding(ctx context.Context, obj *unstructured.Unstructured, opts InstallOpts) error {
+	cr, err := rbac.ClusterRole(opts.Info.Name, opts.Info.RBAC)
+	if err != nil {
+		return fmt.Errorf("%w: use --rbac-mode=%s", err, rbac.ModeAdmin)
+	}
+
+	opts.EventBus.Publish(events.NewStartWaitEvent("Installing %s cluster role", opts.Info.Name))
+	err = core.Apply(ctx, core.ApplyOpts{RESTConfig: opts.RESTConfig, Object: cr, GVK: rbac.ClusterRoleGVK})
+	if err != nil {
+		return err
+	}
+	opts.EventBus.Publish(events.NewObjectApplied(cr, events.ActionApplied))
+
+	return rbac.Restrict(obj, opts.Info.Name)
+}
+
 func isCrossplaneProvider(gvk *schema.GroupVersionKind) bool {
 	return gvk.Group == "pkg.crossplane.io"
 }
@@ -15,7 +15,7 @@ import (
 )
 
 func TestInstall(t *testing.T) {
-	list, err := catalog.FilterBy(catalog.ForCLI())
+	list, err := catalog.FilterBy(catalog.FetchOpts{}, catalog.ForCLI())
 	assert.Nil(t, err, "expecting nil error loading catalog")
 
 	kubeconfig, err := ioutil.ReadFile(clientcmd.RecommendedHomeFile)
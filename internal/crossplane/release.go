@@ -0,0 +1,16 @@
+package crossplane
+
+import (
+	"github.com/platfornow/lash/internal/helm"
+	"k8s.io/client-go/rest"
+)
+
+// ReleaseManifest returns the manifest of the Crossplane Helm release
+// installed in namespace, empty when there is no release.
+func ReleaseManifest(restConfig *rest.Config, namespace string) (string, error) {
+	return helm.ReleaseManifest(helm.GetOptions{
+		RESTConfig:  restConfig,
+		Namespace:   namespace,
+		ReleaseName: chartReleaseName,
+	})
+}
@@ -0,0 +1,256 @@
+// Package diagnostics collects what explains a failed readiness wait: the
+// events of the involved objects, the container statuses and the last log
+// lines of the pods.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/core"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// DefaultTailLines is the number of log lines collected per container.
+const DefaultTailLines = 20
+
+// Object is an object whose events are collected.
+type Object struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+type Opts struct {
+	RESTConfig *rest.Config
+	// Namespace and Selector select the pods, none when Selector is nil.
+	Namespace string
+	Selector  labels.Selector
+	// Objects whose events are collected besides the pods (i.e. the Provider).
+	Objects   []Object
+	TailLines int64
+}
+
+// Container is the status of a pod container.
+type Container struct {
+	Name     string
+	Ready    bool
+	Restarts int32
+	// State is Running, Waiting or Terminated.
+	State string
+	// Reason explains the state (i.e. CrashLoopBackOff, ImagePullBackOff).
+	Reason  string
+	Message string
+	// Logs are the last log lines.
+	Logs string
+}
+
+// Pod is the status of a selected pod.
+type Pod struct {
+	Name       string
+	Namespace  string
+	Phase      string
+	Containers []Container
+}
+
+// Event is an event of an involved object.
+type Event struct {
+	Object  string
+	Type    string
+	Reason  string
+	Message string
+	Count   int32
+	Time    time.Time
+}
+
+// Report are the collected diagnostics.
+type Report struct {
+	Pods   []Pod
+	Events []Event
+}
+
+// Collect returns the diagnostics of the pods and objects of opts; logs
+// that cannot be read are skipped.
+func Collect(ctx context.Context, opts Opts) (*Report, error) {
+	if opts.TailLines <= 0 {
+		opts.TailLines = DefaultTailLines
+	}
+
+	pods := []corev1.Pod{}
+	if opts.Selector != nil {
+		all, err := core.List(ctx, core.ListOpts{
+			RESTConfig:    opts.RESTConfig,
+			GVK:           schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Namespace:     opts.Namespace,
+			LabelSelector: opts.Selector.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, el := range all {
+			pod := corev1.Pod{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(el.UnstructuredContent(), &pod); err != nil {
+				return nil, err
+			}
+			pods = append(pods, pod)
+		}
+	}
+
+	objects := append([]Object{}, opts.Objects...)
+	for _, el := range pods {
+		objects = append(objects, Object{Kind: "Pod", Name: el.Name, Namespace: el.Namespace})
+	}
+
+	evts, err := core.List(ctx, core.ListOpts{
+		RESTConfig: opts.RESTConfig,
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "Event"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := newReport(pods, evts, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Pods) > 0 {
+		cs, err := kubernetes.NewForConfig(opts.RESTConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, pod := range res.Pods {
+			for j, el := range pod.Containers {
+				logs, err := cs.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+					Container: el.Name,
+					TailLines: &opts.TailLines,
+				}).DoRaw(ctx)
+				if err != nil {
+					continue
+				}
+				res.Pods[i].Containers[j].Logs = strings.TrimRight(string(logs), "\n")
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// newReport returns the status of the pods and the events of the objects,
+// oldest first.
+func newReport(pods []corev1.Pod, evts []unstructured.Unstructured, objects []Object) (*Report, error) {
+	res := &Report{}
+
+	for _, el := range pods {
+		res.Pods = append(res.Pods, podOf(&el))
+	}
+
+	wanted := map[string]bool{}
+	for _, el := range objects {
+		wanted[el.Kind+"/"+el.Name] = true
+	}
+
+	for _, el := range evts {
+		evt := corev1.Event{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(el.UnstructuredContent(), &evt); err != nil {
+			return nil, err
+		}
+
+		ref := evt.InvolvedObject.Kind + "/" + evt.InvolvedObject.Name
+		if !wanted[ref] {
+			continue
+		}
+
+		res.Events = append(res.Events, Event{
+			Object:  ref,
+			Type:    evt.Type,
+			Reason:  evt.Reason,
+			Message: strings.TrimSpace(evt.Message),
+			Count:   evt.Count,
+			Time:    eventTime(&evt),
+		})
+	}
+
+	sort.SliceStable(res.Events, func(i, j int) bool {
+		return res.Events[i].Time.Before(res.Events[j].Time)
+	})
+
+	return res, nil
+}
+
+func podOf(pod *corev1.Pod) Pod {
+	res := Pod{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Phase:     string(pod.Status.Phase),
+	}
+
+	all := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	all = append(all, pod.Status.ContainerStatuses...)
+	for _, el := range all {
+		c := Container{
+			Name:     el.Name,
+			Ready:    el.Ready,
+			Restarts: el.RestartCount,
+		}
+
+		switch {
+		case el.State.Waiting != nil:
+			c.State, c.Reason, c.Message = "Waiting", el.State.Waiting.Reason, el.State.Waiting.Message
+		case el.State.Terminated != nil:
+			c.State, c.Reason, c.Message = "Terminated", el.State.Terminated.Reason, el.State.Terminated.Message
+		case el.State.Running != nil:
+			c.State = "Running"
+		}
+
+		// the last termination explains the restarts of a running container
+		if c.State == "Running" && el.LastTerminationState.Terminated != nil && !el.Ready {
+			c.Reason = el.LastTerminationState.Terminated.Reason
+			c.Message = el.LastTerminationState.Terminated.Message
+		}
+
+		res.Containers = append(res.Containers, c)
+	}
+
+	return res
+}
+
+func eventTime(evt *corev1.Event) time.Time {
+	switch {
+	case !evt.LastTimestamp.IsZero():
+		return evt.LastTimestamp.Time
+	case !evt.EventTime.IsZero():
+		return evt.EventTime.Time
+	default:
+		return evt.FirstTimestamp.Time
+	}
+}
+
+// String returns the container status, i.e. Waiting (CrashLoopBackOff: back-off restarting), 3 restarts.
+func (c *Container) String() string {
+	res := c.State
+	if len(res) == 0 {
+		res = "Unknown"
+	}
+
+	if len(c.Reason) > 0 {
+		res = fmt.Sprintf("%s (%s)", res, strings.TrimSuffix(c.Reason+": "+c.Message, ": "))
+	}
+
+	if c.Restarts > 0 {
+		res = fmt.Sprintf("%s, %d restarts", res, c.Restarts)
+	}
+
+	return res
+}
@@ -0,0 +1,102 @@
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func event(kind, name, reason, message, ts string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":     "v1",
+		"kind":           "Event",
+		"metadata":       map[string]interface{}{"name": name + "." + reason},
+		"involvedObject": map[string]interface{}{"kind": kind, "name": name},
+		"type":           "Warning",
+		"reason":         reason,
+		"message":        message,
+		"count":          int64(3),
+		"lastTimestamp":  ts,
+	}}
+}
+
+func TestReport(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "provider-helm-abc", Namespace: "landscape-system"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "package-runtime",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: "Back-off pulling image",
+				}},
+			}, {
+				Name:         "sidecar",
+				RestartCount: 4,
+				State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Reason: "Error",
+				}},
+			}},
+		},
+	}
+
+	evts := []unstructured.Unstructured{
+		event("Pod", "provider-helm-abc", "Failed", "ErrImagePull", "2026-10-19T10:05:00Z"),
+		event("Provider", "provider-helm", "UnpackPackage", "cannot unpack", "2026-10-19T10:01:00Z"),
+		event("Pod", "another", "Failed", "unrelated", "2026-10-19T10:02:00Z"),
+	}
+
+	res, err := newReport([]corev1.Pod{pod}, evts, []Object{
+		{Kind: "Provider", Name: "provider-helm"},
+		{Kind: "Pod", Name: "provider-helm-abc"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Events) != 2 || res.Events[0].Object != "Provider/provider-helm" {
+		t.Fatalf("expected the events of the objects oldest first, got: %+v", res.Events)
+	}
+
+	containers := res.Pods[0].Containers
+	if got := containers[0].String(); got != "Waiting (ImagePullBackOff: Back-off pulling image)" {
+		t.Fatalf("unexpected status: %s", got)
+	}
+	if got := containers[1].String(); got != "Running (Error), 4 restarts" {
+		t.Fatalf("unexpected status: %s", got)
+	}
+
+	res.Pods[0].Containers[0].Logs = "pulling\nfailed"
+
+	buf := strings.Builder{}
+	res.Render(&buf)
+	for _, want := range []string{
+		"landscape-system/provider-helm-abc (Pending)",
+		"      | failed",
+		"Warning Provider/provider-helm UnpackPackage: cannot unpack (x3)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "provider-helm")
+	if err := res.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pods.txt", "events.txt", "provider-helm-abc_package-runtime.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "provider-helm-abc_sidecar.log")); err == nil {
+		t.Fatal("expected no log file without logs")
+	}
+}
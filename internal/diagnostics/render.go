@@ -0,0 +1,132 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+)
+
+// Render writes the diagnostic block: the pods with their containers status
+// and last log lines, then the events.
+func (r *Report) Render(w io.Writer) {
+	if len(r.Pods) == 0 && len(r.Events) == 0 {
+		fmt.Fprintln(w, "no pods or events found")
+		return
+	}
+
+	if len(r.Pods) > 0 {
+		fmt.Fprintln(w, "Pods:")
+		r.renderPods(w, true)
+	}
+
+	if len(r.Events) > 0 {
+		fmt.Fprintln(w, "Events:")
+		r.renderEvents(w)
+	}
+}
+
+func (r *Report) renderPods(w io.Writer, logs bool) {
+	for _, pod := range r.Pods {
+		fmt.Fprintf(w, "  %s/%s (%s)\n", pod.Namespace, pod.Name, pod.Phase)
+		for _, el := range pod.Containers {
+			fmt.Fprintf(w, "    %s: %s\n", el.Name, el.String())
+			if !logs || len(el.Logs) == 0 {
+				continue
+			}
+			for _, line := range strings.Split(el.Logs, "\n") {
+				fmt.Fprintf(w, "      | %s\n", line)
+			}
+		}
+	}
+}
+
+func (r *Report) renderEvents(w io.Writer) {
+	for _, el := range r.Events {
+		ts := "-"
+		if !el.Time.IsZero() {
+			ts = el.Time.Format("15:04:05")
+		}
+
+		count := ""
+		if el.Count > 1 {
+			count = fmt.Sprintf(" (x%d)", el.Count)
+		}
+
+		fmt.Fprintf(w, "  %s %s %s %s: %s%s\n", ts, el.Type, el.Object, el.Reason, el.Message, count)
+	}
+}
+
+// Save writes the report to dir: pods.txt with the containers status,
+// events.txt and a <pod>_<container>.log file per container.
+func (r *Report) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	pods := strings.Builder{}
+	r.renderPods(&pods, false)
+	if err := os.WriteFile(filepath.Join(dir, "pods.txt"), []byte(pods.String()), 0o644); err != nil {
+		return err
+	}
+
+	evts := strings.Builder{}
+	r.renderEvents(&evts)
+	if err := os.WriteFile(filepath.Join(dir, "events.txt"), []byte(evts.String()), 0o644); err != nil {
+		return err
+	}
+
+	for _, pod := range r.Pods {
+		for _, el := range pod.Containers {
+			if len(el.Logs) == 0 {
+				continue
+			}
+			name := fmt.Sprintf("%s_%s.log", pod.Name, el.Name)
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(el.Logs+"\n"), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Dir returns the directory where the diagnostics of name are saved under
+// base, empty when base is not set.
+func Dir(base, name string) string {
+	if len(base) == 0 {
+		return ""
+	}
+	return filepath.Join(base, name)
+}
+
+// Publish collects the diagnostics, publishes them as a warning about what
+// failed and, when dir is set, saves them there.
+func Publish(ctx context.Context, bus eventbus.BusPublisher, what string, opts Opts, dir string) {
+	res, err := Collect(ctx, opts)
+	if err != nil {
+		bus.Publish(events.NewWarningEvent("cannot collect the diagnostics of %s: %s", what, err))
+		return
+	}
+
+	buf := strings.Builder{}
+	res.Render(&buf)
+
+	bus.Publish(events.NewStopWaitEvent())
+	bus.Publish(events.NewWarningEvent("diagnostics of %s:\n%s", what, buf.String()))
+
+	if len(dir) == 0 {
+		return
+	}
+
+	if err := res.Save(dir); err != nil {
+		bus.Publish(events.NewWarningEvent("cannot save the diagnostics of %s: %s", what, err))
+		return
+	}
+	bus.Publish(events.NewWarningEvent("diagnostics of %s saved to %s", what, dir))
+}
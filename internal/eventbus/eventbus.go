@@ -3,16 +3,34 @@
 // There are differences in API and implementation
 //  - deadlock prevention: this eventbus doesn't lock mutex when callbacks are called
 //  - API based on EventID and Event interface instead of strings and variadic arguments list
+//  - prefix subscriptions (i.e. "log:*") and async subscriptions with ordered delivery
 
 package eventbus
 
 import (
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // EventID identifies events topic.
+// A subscription EventID ending with Wildcard matches the events whose id
+// starts with the preceding prefix (i.e. "log:*"), Wildcard alone matches
+// any event.
 type EventID string
 
+// Wildcard is the suffix of the prefix subscriptions.
+const Wildcard = "*"
+
+// Matches returns true when the event id is matched by the subscription id.
+func (id EventID) Matches(eventID EventID) bool {
+	if prefix, ok := strings.CutSuffix(string(id), Wildcard); ok {
+		return strings.HasPrefix(string(eventID), prefix)
+	}
+	return id == eventID
+}
+
 // Event must be implemented by anything that can be published
 type Event interface {
 	EventID() EventID
@@ -30,6 +48,12 @@ type Subscription struct {
 // BusSubscriber allows to subscribe/unsubscribe own event handlers
 type BusSubscriber interface {
 	Subscribe(eventID EventID, cb EventHandler) Subscription
+	// SubscribeAsync subscribes a handler called from its own goroutine:
+	// Publish queues the events, up to buffer, and blocks while the queue is
+	// full. The handler receives the events in the order they are published.
+	SubscribeAsync(eventID EventID, cb EventHandler, buffer int) Subscription
+	// SubscribeOnce subscribes a handler unsubscribed after the first event.
+	SubscribeOnce(eventID EventID, cb EventHandler) Subscription
 	Unsubscribe(id Subscription)
 }
 
@@ -42,6 +66,10 @@ type BusPublisher interface {
 type Bus interface {
 	BusSubscriber
 	BusPublisher
+	// Close stops the delivery of the events published afterwards and waits
+	// for the async handlers to drain their queues. It must not be called
+	// from a handler.
+	Close()
 }
 
 // New returns new event bus
@@ -53,8 +81,14 @@ func New() Bus {
 }
 
 type subscriptionInfo struct {
-	id uint64
-	cb EventHandler
+	eventID EventID
+	id      uint64
+	cb      EventHandler
+	// queue is not nil for the async subscriptions
+	queue *queue
+	// fired is set by the first event of the once subscriptions
+	once  bool
+	fired atomic.Bool
 }
 
 type subscriptionInfoList []*subscriptionInfo
@@ -63,16 +97,40 @@ type bus struct {
 	lock   sync.Mutex
 	nextID uint64
 	infos  map[EventID]subscriptionInfoList
+	closed bool
+	// drained waits for the async handlers
+	drained sync.WaitGroup
 }
 
 func (bus *bus) Subscribe(eventID EventID, cb EventHandler) Subscription {
+	return bus.subscribe(eventID, &subscriptionInfo{cb: cb})
+}
+
+func (bus *bus) SubscribeAsync(eventID EventID, cb EventHandler, buffer int) Subscription {
+	q := &queue{events: make(chan Event, buffer), done: make(chan struct{})}
+
+	bus.drained.Add(1)
+	go func() {
+		defer bus.drained.Done()
+		q.run(cb)
+	}()
+
+	return bus.subscribe(eventID, &subscriptionInfo{cb: cb, queue: q})
+}
+
+func (bus *bus) SubscribeOnce(eventID EventID, cb EventHandler) Subscription {
+	return bus.subscribe(eventID, &subscriptionInfo{cb: cb, once: true})
+}
+
+func (bus *bus) subscribe(eventID EventID, sub *subscriptionInfo) Subscription {
 	bus.lock.Lock()
 	defer bus.lock.Unlock()
 	id := bus.nextID
 	bus.nextID++
-	sub := &subscriptionInfo{
-		id: id,
-		cb: cb,
+	sub.eventID = eventID
+	sub.id = id
+	if bus.closed && sub.queue != nil {
+		sub.queue.close()
 	}
 	bus.infos[eventID] = append(bus.infos[eventID], sub)
 	return Subscription{
@@ -82,39 +140,149 @@ func (bus *bus) Subscribe(eventID EventID, cb EventHandler) Subscription {
 }
 
 func (bus *bus) Unsubscribe(subscription Subscription) {
-	bus.lock.Lock()
-	defer bus.lock.Unlock()
+	var removed *subscriptionInfo
 
+	bus.lock.Lock()
 	if infos, ok := bus.infos[subscription.eventID]; ok {
-		for idx, info := range infos {
+		res := make(subscriptionInfoList, 0, len(infos))
+		for _, info := range infos {
 			if info.id == subscription.id {
-				infos = append(infos[:idx], infos[idx+1:]...)
-				break
+				removed = info
+				continue
 			}
+			res = append(res, info)
 		}
-		if len(infos) == 0 {
+		if len(res) == 0 {
 			delete(bus.infos, subscription.eventID)
 		} else {
-			bus.infos[subscription.eventID] = infos
+			bus.infos[subscription.eventID] = res
 		}
 	}
+	bus.lock.Unlock()
+
+	// the queued events are still delivered; the queue is closed without
+	// holding the bus lock since a pending push waits for the handler
+	if removed != nil && removed.queue != nil {
+		removed.queue.close()
+	}
 }
 
 func (bus *bus) Publish(event Event) {
 	infos := bus.copySubscriptions(event.EventID())
 	for _, sub := range infos {
+		if sub.once {
+			if !sub.fired.CompareAndSwap(false, true) {
+				continue
+			}
+			bus.Unsubscribe(Subscription{eventID: sub.eventID, id: sub.id})
+		}
+
+		if sub.queue != nil {
+			sub.queue.push(event)
+			continue
+		}
 		sub.cb(event)
 	}
 }
 
+func (bus *bus) Close() {
+	queues := []*queue{}
+
+	bus.lock.Lock()
+	bus.closed = true
+	for _, infos := range bus.infos {
+		for _, info := range infos {
+			if info.queue != nil {
+				queues = append(queues, info.queue)
+			}
+		}
+	}
+	bus.lock.Unlock()
+
+	for _, el := range queues {
+		el.close()
+	}
+	bus.drained.Wait()
+}
+
 func (bus *bus) copySubscriptions(eventID EventID) subscriptionInfoList {
 	// External code may subscribe/unsubscribe during iteration over callbacks,
 	//  so we need to copy subscribers to invoke callbacks.
 
 	bus.lock.Lock()
 	defer bus.lock.Unlock()
-	if infos, ok := bus.infos[eventID]; ok {
-		return infos
+	if bus.closed {
+		return subscriptionInfoList{}
+	}
+
+	res := subscriptionInfoList{}
+	for k, infos := range bus.infos {
+		if k.Matches(eventID) {
+			res = append(res, infos...)
+		}
+	}
+	// in subscription order
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].id < res[j].id
+	})
+	return res
+}
+
+// queue is the buffer of an async subscription.
+type queue struct {
+	lock   sync.Mutex
+	closed bool
+	// pending counts the pushes in progress
+	pending sync.WaitGroup
+	events  chan Event
+	done    chan struct{}
+}
+
+// push queues the event, blocking while the buffer is full. The event is
+// sent without holding the lock so that the handler can unsubscribe itself
+// while a push is blocked; a blocked push is dropped when the queue closes.
+func (q *queue) push(e Event) {
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return
+	}
+	q.pending.Add(1)
+	q.lock.Unlock()
+	defer q.pending.Done()
+
+	select {
+	case q.events <- e:
+	case <-q.done:
+	}
+}
+
+// run calls the handler until the queue is closed, then delivers the
+// events queued before.
+func (q *queue) run(cb EventHandler) {
+	for {
+		select {
+		case e := <-q.events:
+			cb(e)
+		case <-q.done:
+			q.pending.Wait()
+			for {
+				select {
+				case e := <-q.events:
+					cb(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *queue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
 	}
-	return subscriptionInfoList{}
 }
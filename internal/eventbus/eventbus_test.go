@@ -1,6 +1,7 @@
 package eventbus
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -167,3 +168,186 @@ func TestBus_PublishRecursive(t *testing.T) {
 
 	assert.Equal(t, moonEventCount, 5)
 }
+
+func TestEventID_Matches(t *testing.T) {
+	assert.True(t, EventID("log:*").Matches("log:debug"))
+	assert.True(t, EventID("*").Matches(eventMoonEclipse))
+	assert.True(t, eventMoonEclipse.Matches(eventMoonEclipse))
+	assert.False(t, EventID("log:*").Matches("step:started"))
+	assert.False(t, EventID("moon").Matches(eventMoonEclipse))
+}
+
+func TestBus_SubscribeWildcard(t *testing.T) {
+	bus := New()
+	got := []EventID{}
+
+	bus.Subscribe(eventMoonEclipse, func(e Event) {
+		got = append(got, "exact")
+	})
+	id := bus.Subscribe("*_eclipse", func(e Event) {
+		t.Fatalf("only trailing wildcards are supported")
+	})
+	bus.Subscribe("moon*", func(e Event) {
+		got = append(got, e.EventID())
+	})
+	bus.Subscribe("*", func(e Event) {
+		got = append(got, "all")
+	})
+
+	bus.Publish(&moonEclipseEvent{})
+	bus.Publish(&solarEclipseEvent{})
+	assert.Equal(t, []EventID{"exact", eventMoonEclipse, "all", "all"}, got)
+
+	bus.Unsubscribe(id)
+}
+
+func TestBus_SubscribeOnce(t *testing.T) {
+	bus := New()
+	count := 0
+	bus.SubscribeOnce(eventMoonEclipse, func(e Event) {
+		count++
+		// recursive publish is not delivered again
+		bus.Publish(&moonEclipseEvent{})
+	})
+
+	bus.Publish(&moonEclipseEvent{})
+	bus.Publish(&moonEclipseEvent{})
+	assert.Equal(t, 1, count)
+}
+
+func TestBus_SubscribeOnceConcurrent(t *testing.T) {
+	bus := New()
+	mu := sync.Mutex{}
+	count := 0
+	bus.SubscribeOnce(eventSolarEclipse, func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+	})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bus.Publish(&solarEclipseEvent{})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, count)
+}
+
+func TestBus_SubscribeAsync(t *testing.T) {
+	bus := New()
+	got := []time.Duration{}
+	bus.SubscribeAsync(eventMoonEclipse, func(e Event) {
+		// slower than the publisher, the queue gets full
+		time.Sleep(time.Millisecond)
+		got = append(got, e.(*moonEclipseEvent).duration)
+	}, 2)
+
+	for i := 0; i < 10; i++ {
+		bus.Publish(&moonEclipseEvent{duration: time.Duration(i)})
+	}
+	bus.Close()
+
+	want := []time.Duration{}
+	for i := 0; i < 10; i++ {
+		want = append(want, time.Duration(i))
+	}
+	assert.Equal(t, want, got)
+
+	// published after Close
+	bus.Publish(&moonEclipseEvent{})
+	assert.Len(t, got, 10)
+}
+
+func TestBus_UnsubscribeAsync(t *testing.T) {
+	bus := New()
+	mu := sync.Mutex{}
+	count := 0
+	id := bus.SubscribeAsync(eventSolarEclipse, func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+	}, 10)
+
+	bus.Publish(&solarEclipseEvent{})
+	bus.Publish(&solarEclipseEvent{})
+	bus.Unsubscribe(id)
+	bus.Publish(&solarEclipseEvent{})
+
+	// the events queued before Unsubscribe are delivered
+	bus.Close()
+	assert.Equal(t, 2, count)
+}
+
+func TestBus_UnsubscribeAsyncFromHandler(t *testing.T) {
+	bus := New()
+	var id Subscription
+	started := make(chan struct{})
+	release := make(chan struct{})
+	count := 0
+	id = bus.SubscribeAsync(eventSolarEclipse, func(e Event) {
+		if count == 0 {
+			close(started)
+			<-release
+			bus.Unsubscribe(id)
+		}
+		count++
+	}, 0)
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		bus.Publish(&solarEclipseEvent{})
+		<-started
+		// blocks until the handler unsubscribes
+		bus.Publish(&solarEclipseEvent{})
+	}()
+	<-started
+	// lets the second Publish block on the handler
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish is blocked by the unsubscribed handler")
+	}
+	bus.Close()
+	assert.GreaterOrEqual(t, count, 1)
+}
+
+func TestBus_ConcurrentPublishSubscribe(t *testing.T) {
+	bus := New()
+	mu := sync.Mutex{}
+	count := 0
+	handler := func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+	}
+	bus.SubscribeAsync("*", handler, 1)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				bus.Publish(&moonEclipseEvent{})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			bus.Unsubscribe(bus.Subscribe(eventMoonEclipse, handler))
+			bus.Unsubscribe(bus.SubscribeAsync(eventMoonEclipse, handler, 0))
+		}()
+	}
+	wg.Wait()
+	bus.Close()
+
+	// the wildcard async handler got all the events
+	assert.GreaterOrEqual(t, count, 100)
+}
@@ -10,6 +10,7 @@ const (
 	StartWaitEventID = eventbus.EventID("log:startWait")
 	StopWaitEventID  = eventbus.EventID("log:stopWait")
 	DoneEventID      = eventbus.EventID("log:done")
+	InfoEventID      = eventbus.EventID("log:info")
 	DebugEventID     = eventbus.EventID("log:debug")
 	WarningEventID   = eventbus.EventID("log:warn")
 )
@@ -56,6 +57,22 @@ func (e *DoneEvent) Message() string {
 	return e.message
 }
 
+func NewInfoEvent(s string, args ...interface{}) *InfoEvent {
+	return &InfoEvent{fmt.Sprintf(s, args...)}
+}
+
+type InfoEvent struct {
+	message string
+}
+
+func (e *InfoEvent) EventID() eventbus.EventID {
+	return InfoEventID
+}
+
+func (e *InfoEvent) Message() string {
+	return e.message
+}
+
 func NewDebugEvent(s string, args ...interface{}) *DebugEvent {
 	return &DebugEvent{fmt.Sprintf(s, args...)}
 }
@@ -5,6 +5,23 @@ import (
 	"github.com/platfornow/lash/internal/log"
 )
 
+// EventIDs are the subscription ids of the events rendered by LogHandler.
+var EventIDs = []eventbus.EventID{
+	"log:*",
+	"step:*",
+	"object:*",
+	"wait:*",
+}
+
+// Subscribe subscribes the handler to all the EventIDs.
+func Subscribe(bus eventbus.BusSubscriber, handler eventbus.EventHandler) []eventbus.Subscription {
+	res := make([]eventbus.Subscription, 0, len(EventIDs))
+	for _, el := range EventIDs {
+		res = append(res, bus.Subscribe(el, handler))
+	}
+	return res
+}
+
 func LogHandler(l log.Logger) eventbus.EventHandler {
 	return func(e eventbus.Event) {
 		switch e.EventID() {
@@ -12,6 +29,11 @@ func LogHandler(l log.Logger) eventbus.EventHandler {
 			evt := e.(*DebugEvent)
 			l.Debug(evt.Message())
 
+		case InfoEventID:
+			evt := e.(*InfoEvent)
+			l.StopWait()
+			l.Info(evt.Message())
+
 		case WarningEventID:
 			evt := e.(*WarningEvent)
 			l.Warn(evt.Message())
@@ -27,6 +49,42 @@ func LogHandler(l log.Logger) eventbus.EventHandler {
 			evt := e.(*DoneEvent)
 			l.StopWait()
 			l.Done(evt.Message())
+
+		case StepStartedEventID:
+			evt := e.(*StepStarted)
+			withObject(l, evt.Object).StartWait(evt.String())
+
+		case StepCompletedEventID:
+			evt := e.(*StepCompleted)
+			l.StopWait()
+			l.Done(evt.String())
+
+		case FailureEventID:
+			// the error is reported by the command
+			l.StopWait()
+
+		case ObjectAppliedEventID:
+			evt := e.(*ObjectApplied)
+			l.StopWait()
+			withObject(l, evt.Object()).Done(evt.String())
+
+		case WaitProgressEventID:
+			evt := e.(*WaitProgress)
+			withObject(l, evt.Object).StartWait(evt.String())
 		}
 	}
 }
+
+// withObject returns the logger adding the object to the JSON entries.
+func withObject(l log.Logger, obj *Object) log.Logger {
+	if obj == nil {
+		return l
+	}
+
+	return log.WithObjects(l, log.ObjectRef{
+		APIVersion: obj.GVK.GroupVersion().String(),
+		Kind:       obj.GVK.Kind,
+		Name:       obj.Name,
+		Namespace:  obj.Namespace,
+	})
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLogHandler(t *testing.T) {
+	buf := bytes.Buffer{}
+	bus := eventbus.New()
+	Subscribe(bus, LogHandler(log.NewJSONLogger(&buf, log.DebugLevel)))
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "pkg.crossplane.io", Version: "v1", Kind: "Provider"})
+	obj.SetName("provider-helm")
+
+	bus.Publish(NewStepStarted("providers", nil))
+	bus.Publish(NewObjectApplied(obj, ActionApplied))
+	bus.Publish(&WaitProgress{Phase: "providers", Object: ObjectOf(obj), Timeout: 3 * time.Minute})
+	bus.Publish(&WaitProgress{Phase: "claims", Object: &Object{GVK: obj.GroupVersionKind(), Name: "core"}, Ready: 2, Total: 5})
+	bus.Publish(NewInfoEvent("Core/core  Synced=True Ready=False"))
+	bus.Publish(NewStepCompleted("providers", 1500*time.Millisecond))
+	bus.Publish(NewFailure("claims", errors.New("boom")))
+
+	entries := []log.Entry{}
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var el log.Entry
+		if err := json.Unmarshal(sc.Bytes(), &el); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, el)
+	}
+
+	want := []string{
+		"providers...",
+		"Provider/provider-helm applied",
+		"waiting for Provider/provider-helm readiness (timeout 3m0s)...",
+		"waiting for Provider/core readiness (2/5 resources ready)...",
+		"Core/core  Synced=True Ready=False",
+		"providers completed in 1.5s",
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got: %+v", len(want), entries)
+	}
+	for i, el := range want {
+		if entries[i].Message != el {
+			t.Fatalf("expected %q, got %q", el, entries[i].Message)
+		}
+	}
+
+	objs := entries[1].Objects
+	if len(objs) != 1 || objs[0].APIVersion != "pkg.crossplane.io/v1" || objs[0].Name != "provider-helm" {
+		t.Fatalf("unexpected objects: %+v", objs)
+	}
+}
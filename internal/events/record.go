@@ -0,0 +1,169 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+)
+
+// maxRecordSize bounds a recorded event (i.e. a debug event with a YAML).
+const maxRecordSize = 16 * 1024 * 1024
+
+// Record is a recorded event, a JSON line of the recordings.
+type Record struct {
+	Time time.Time        `json:"time"`
+	ID   eventbus.EventID `json:"id"`
+	// Message of the log events.
+	Message string `json:"message,omitempty"`
+	// Event are the fields of the typed events.
+	Event json.RawMessage `json:"event,omitempty"`
+}
+
+// messageEvents build the log events from their message.
+var messageEvents = map[eventbus.EventID]func(string) eventbus.Event{
+	StartWaitEventID: func(s string) eventbus.Event { return NewStartWaitEvent("%s", s) },
+	DoneEventID:      func(s string) eventbus.Event { return NewDoneEvent("%s", s) },
+	InfoEventID:      func(s string) eventbus.Event { return NewInfoEvent("%s", s) },
+	DebugEventID:     func(s string) eventbus.Event { return NewDebugEvent("%s", s) },
+	WarningEventID:   func(s string) eventbus.Event { return NewWarningEvent("%s", s) },
+}
+
+// typedEvents return the events decoded from their fields.
+var typedEvents = map[eventbus.EventID]func() eventbus.Event{
+	StopWaitEventID:      func() eventbus.Event { return &StopWaitEvent{} },
+	StepStartedEventID:   func() eventbus.Event { return &StepStarted{} },
+	StepCompletedEventID: func() eventbus.Event { return &StepCompleted{} },
+	FailureEventID:       func() eventbus.Event { return &Failure{} },
+	ObjectAppliedEventID: func() eventbus.Event { return &ObjectApplied{} },
+	WaitProgressEventID:  func() eventbus.Event { return &WaitProgress{} },
+}
+
+// NewRecord returns the record of the event published at t.
+func NewRecord(t time.Time, e eventbus.Event) (*Record, error) {
+	res := &Record{Time: t.UTC(), ID: e.EventID()}
+
+	if m, ok := e.(interface{ Message() string }); ok {
+		res.Message = m.Message()
+		return res, nil
+	}
+
+	dat, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	res.Event = dat
+
+	return res, nil
+}
+
+// Decode returns the recorded event, nil when the event is unknown.
+func (r *Record) Decode() (eventbus.Event, error) {
+	if fn, ok := messageEvents[r.ID]; ok {
+		return fn(r.Message), nil
+	}
+
+	fn, ok := typedEvents[r.ID]
+	if !ok {
+		return nil, nil
+	}
+
+	res := fn()
+	if len(r.Event) > 0 {
+		if err := json.Unmarshal(r.Event, res); err != nil {
+			return nil, fmt.Errorf("decoding %s event: %w", r.ID, err)
+		}
+	}
+	return res, nil
+}
+
+// Recorder writes the events as JSON lines.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+	// err is the first event that could not be recorded
+	err error
+}
+
+// NewRecorder returns a recorder writing to w; subscribe its Handle to
+// eventbus.Wildcard to record all the events.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, now: time.Now}
+}
+
+// Handle records the event; the events that cannot be encoded or written
+// are skipped, the first error is returned by Err.
+func (r *Recorder) Handle(e eventbus.Event) {
+	dat, err := r.encode(e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		_, err = r.w.Write(append(dat, '\n'))
+	}
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("recording %s event: %w", e.EventID(), err)
+	}
+}
+
+// Err returns the first error of the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) encode(e eventbus.Event) ([]byte, error) {
+	rec, err := NewRecord(r.now(), e)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rec)
+}
+
+// Replay publishes the recorded events keeping the time between them,
+// divided by speed; with speed 0 the events are published at once. The
+// unknown events are skipped.
+func Replay(ctx context.Context, r io.Reader, bus eventbus.BusPublisher, speed float64) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	var last time.Time
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		evt, err := rec.Decode()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if speed > 0 && !last.IsZero() && rec.Time.After(last) {
+			d := time.Duration(float64(rec.Time.Sub(last)) / speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+			}
+		}
+		last = rec.Time
+
+		if evt != nil {
+			bus.Publish(evt)
+		}
+	}
+
+	return sc.Err()
+}
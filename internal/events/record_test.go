@@ -0,0 +1,105 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRecordReplay(t *testing.T) {
+	buf := bytes.Buffer{}
+	rec := NewRecorder(&buf)
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	now := start
+	rec.now = func() time.Time {
+		now = now.Add(20 * time.Millisecond)
+		return now
+	}
+
+	bus := eventbus.New()
+	bus.Subscribe(eventbus.Wildcard, rec.Handle)
+
+	gvk := schema.GroupVersionKind{Group: "pkg.crossplane.io", Version: "v1", Kind: "Provider"}
+	bus.Publish(NewStepStarted("providers", nil))
+	bus.Publish(NewStartWaitEvent("installing %s...", "provider-helm"))
+	bus.Publish(&ObjectApplied{GVK: gvk, Name: "provider-helm", Action: ActionApplied})
+	bus.Publish(NewStopWaitEvent())
+	bus.Publish(NewFailure("providers", errors.New("boom")))
+
+	// events unknown to the replay are skipped
+	buf.WriteString(`{"time":"2026-10-19T10:00:00.12Z","id":"custom:event"}` + "\n")
+
+	got := []eventbus.Event{}
+	replayed := eventbus.New()
+	replayed.Subscribe(eventbus.Wildcard, func(e eventbus.Event) {
+		got = append(got, e)
+	})
+
+	begin := time.Now()
+	if err := Replay(context.Background(), strings.NewReader(buf.String()), replayed, 2); err != nil {
+		t.Fatal(err)
+	}
+	// 4 gaps of 20ms, plus the gap to the custom event, at twice the speed
+	if elapsed := time.Since(begin); elapsed < 40*time.Millisecond {
+		t.Fatalf("expected the recorded timings, took %s", elapsed)
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("expected 5 events, got: %+v", got)
+	}
+	if evt, ok := got[1].(*StartWaitEvent); !ok || evt.Message() != "installing provider-helm..." {
+		t.Fatalf("unexpected start wait event: %+v", got[1])
+	}
+	if evt, ok := got[2].(*ObjectApplied); !ok || evt.GVK != gvk || evt.Name != "provider-helm" || evt.Action != ActionApplied {
+		t.Fatalf("unexpected object applied event: %+v", got[2])
+	}
+	if _, ok := got[3].(*StopWaitEvent); !ok {
+		t.Fatalf("unexpected stop wait event: %+v", got[3])
+	}
+	if evt, ok := got[4].(*Failure); !ok || evt.Phase != "providers" || evt.Err.Error() != "boom" {
+		t.Fatalf("unexpected failure event: %+v", got[4])
+	}
+}
+
+func TestReplayInvalid(t *testing.T) {
+	err := Replay(context.Background(), strings.NewReader("{}\nnot json\n"), eventbus.New(), 0)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Fatalf("expected the line of the error, got: %v", err)
+	}
+}
+
+// failingWriter fails after n writes.
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("no space left on device")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestRecorderErr(t *testing.T) {
+	rec := NewRecorder(&failingWriter{n: 1})
+
+	rec.Handle(NewStepStarted("providers", nil))
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec.Handle(NewStopWaitEvent())
+	rec.Handle(NewFailure("providers", errors.New("boom")))
+	err := rec.Err()
+	if err == nil || err.Error() != "recording log:stopWait event: no space left on device" {
+		t.Fatalf("expected the first write error, got: %v", err)
+	}
+}
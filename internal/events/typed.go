@@ -0,0 +1,199 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	StepStartedEventID   = eventbus.EventID("step:started")
+	StepCompletedEventID = eventbus.EventID("step:completed")
+	FailureEventID       = eventbus.EventID("step:failed")
+	ObjectAppliedEventID = eventbus.EventID("object:applied")
+	WaitProgressEventID  = eventbus.EventID("wait:progress")
+)
+
+// Actions of the ObjectApplied events.
+const (
+	ActionApplied = "applied"
+	ActionDeleted = "deleted"
+)
+
+// Object references the Kubernetes object an event is about.
+type Object struct {
+	GVK       schema.GroupVersionKind `json:"gvk"`
+	Name      string                  `json:"name"`
+	Namespace string                  `json:"namespace,omitempty"`
+}
+
+// ObjectOf returns the reference to obj.
+func ObjectOf(obj *unstructured.Unstructured) *Object {
+	return &Object{
+		GVK:       obj.GroupVersionKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+}
+
+// String returns kind/name or kind/namespace/name.
+func (o *Object) String() string {
+	if len(o.Namespace) > 0 {
+		return fmt.Sprintf("%s/%s/%s", o.GVK.Kind, o.Namespace, o.Name)
+	}
+	return fmt.Sprintf("%s/%s", o.GVK.Kind, o.Name)
+}
+
+func NewStepStarted(phase string, obj *Object) *StepStarted {
+	return &StepStarted{Phase: phase, Object: obj}
+}
+
+// StepStarted is published when a phase of a command starts, Object is
+// optional.
+type StepStarted struct {
+	Phase  string  `json:"phase"`
+	Object *Object `json:"object,omitempty"`
+}
+
+func (e *StepStarted) EventID() eventbus.EventID {
+	return StepStartedEventID
+}
+
+func (e *StepStarted) String() string {
+	if e.Object != nil {
+		return fmt.Sprintf("%s %s...", e.Phase, e.Object)
+	}
+	return fmt.Sprintf("%s...", e.Phase)
+}
+
+func NewStepCompleted(phase string, d time.Duration) *StepCompleted {
+	return &StepCompleted{Phase: phase, Duration: d}
+}
+
+// StepCompleted is published when a phase of a command succeeds.
+type StepCompleted struct {
+	Phase    string        `json:"phase"`
+	Duration time.Duration `json:"duration"`
+}
+
+func (e *StepCompleted) EventID() eventbus.EventID {
+	return StepCompletedEventID
+}
+
+func (e *StepCompleted) String() string {
+	return fmt.Sprintf("%s completed in %s", e.Phase, e.Duration.Round(time.Millisecond))
+}
+
+func NewFailure(phase string, err error) *Failure {
+	return &Failure{Phase: phase, Err: err}
+}
+
+// Failure is published when a phase of a command fails.
+type Failure struct {
+	Phase string `json:"phase"`
+	Err   error  `json:"-"`
+}
+
+func (e *Failure) EventID() eventbus.EventID {
+	return FailureEventID
+}
+
+func (e *Failure) String() string {
+	return fmt.Sprintf("%s failed: %s", e.Phase, e.Err)
+}
+
+type failureJSON struct {
+	Phase string `json:"phase"`
+	Error string `json:"error"`
+}
+
+// MarshalJSON encodes the error message.
+func (e *Failure) MarshalJSON() ([]byte, error) {
+	res := failureJSON{Phase: e.Phase}
+	if e.Err != nil {
+		res.Error = e.Err.Error()
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON decodes the error message as a plain error.
+func (e *Failure) UnmarshalJSON(data []byte) error {
+	var res failureJSON
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	e.Phase = res.Phase
+	e.Err = errors.New(res.Error)
+	return nil
+}
+
+func NewObjectApplied(obj *unstructured.Unstructured, action string) *ObjectApplied {
+	return &ObjectApplied{
+		GVK:       obj.GroupVersionKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Action:    action,
+	}
+}
+
+// ObjectApplied is published when an object is applied to or deleted from
+// the cluster.
+type ObjectApplied struct {
+	GVK       schema.GroupVersionKind `json:"gvk"`
+	Name      string                  `json:"name"`
+	Namespace string                  `json:"namespace,omitempty"`
+	// Action is ActionApplied or ActionDeleted.
+	Action string `json:"action"`
+}
+
+func (e *ObjectApplied) EventID() eventbus.EventID {
+	return ObjectAppliedEventID
+}
+
+func (e *ObjectApplied) Object() *Object {
+	return &Object{GVK: e.GVK, Name: e.Name, Namespace: e.Namespace}
+}
+
+func (e *ObjectApplied) String() string {
+	return fmt.Sprintf("%s %s", e.Object(), e.Action)
+}
+
+// Condition is a status condition of a waited object.
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// WaitProgress is published while waiting for an object readiness.
+type WaitProgress struct {
+	Phase  string  `json:"phase"`
+	Object *Object `json:"object"`
+	// Ready and Total count the ready objects when the wait covers a tree
+	// of objects (i.e. a claim and its composed resources).
+	Ready      int         `json:"ready,omitempty"`
+	Total      int         `json:"total,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Timeout of the wait, when known.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+func (e *WaitProgress) EventID() eventbus.EventID {
+	return WaitProgressEventID
+}
+
+func (e *WaitProgress) String() string {
+	if e.Total > 0 {
+		return fmt.Sprintf("waiting for %s readiness (%d/%d resources ready)...", e.Object, e.Ready, e.Total)
+	}
+	if e.Timeout > 0 {
+		return fmt.Sprintf("waiting for %s readiness (timeout %s)...", e.Object, e.Timeout)
+	}
+	return fmt.Sprintf("waiting for %s readiness...", e.Object)
+}
@@ -0,0 +1,77 @@
+// Package fanout runs a command against many kubeconfig contexts.
+package fanout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the number of contexts processed at the same time.
+const DefaultConcurrency = 4
+
+// Result is the outcome of the run against one context.
+type Result struct {
+	Context  string
+	Err      error
+	Duration time.Duration
+}
+
+// Func runs the command against a kubeconfig context.
+type Func func(ctx context.Context, kubeContext string) error
+
+// Run calls fn for each context, at most concurrency at a time, and
+// returns the results in the same order of the contexts. Contexts not
+// started yet when ctx is cancelled fail with the context error.
+func Run(ctx context.Context, contexts []string, concurrency int, fn Func) []Result {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	res := make([]Result, len(contexts))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, name := range contexts {
+		res[i].Context = name
+
+		if err := ctx.Err(); err != nil {
+			res[i].Err = err
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			res[i].Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			res[i].Err = fn(ctx, name)
+			res[i].Duration = time.Since(start)
+		}(i, name)
+	}
+
+	wg.Wait()
+
+	return res
+}
+
+// Failed returns the number of failed results.
+func Failed(all []Result) int {
+	n := 0
+	for _, el := range all {
+		if el.Err != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBoundedConcurrency(t *testing.T) {
+	var running, peak int32
+
+	contexts := []string{"a", "b", "c", "d", "e", "f"}
+	res := Run(context.Background(), contexts, 2, func(ctx context.Context, name string) error {
+		cur := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		if name == "c" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent runs, got: %d", peak)
+	}
+
+	if len(res) != len(contexts) {
+		t.Fatalf("expected %d results, got: %d", len(contexts), len(res))
+	}
+
+	for i, el := range res {
+		if el.Context != contexts[i] {
+			t.Fatalf("expected results in order, got: %s at %d", el.Context, i)
+		}
+		if (el.Err != nil) != (el.Context == "c") {
+			t.Fatalf("%s: unexpected error: %v", el.Context, el.Err)
+		}
+	}
+
+	if n := Failed(res); n != 1 {
+		t.Fatalf("expected 1 failure, got: %d", n)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := Run(ctx, []string{"a", "b"}, 1, func(ctx context.Context, name string) error {
+		t.Fatalf("unexpected run for %s", name)
+		return nil
+	})
+
+	if n := Failed(res); n != 2 {
+		t.Fatalf("expected 2 failures, got: %d", n)
+	}
+}
@@ -1,6 +1,7 @@
 package helm
 
 import (
+	"context"
 	"errors"
 	"io"
 	"strings"
@@ -30,7 +31,9 @@ type InstallOptions struct {
 	LogFn       func(format string, v ...interface{})
 }
 
-func Install(opts InstallOptions) error {
+// Install installs the chart, unless the release exists; it stops when ctx
+// is cancelled.
+func Install(ctx context.Context, opts InstallOptions) error {
 	rg := newRESTClientGetter(opts.RESTConfig, opts.Namespace)
 
 	actionConfig := new(action.Configuration)
@@ -50,7 +53,7 @@ func Install(opts InstallOptions) error {
 	iCli.Wait = false
 	iCli.Timeout = 10 * time.Second
 
-	_, err = iCli.Run(chart, opts.ChartValues)
+	_, err = iCli.RunWithContext(ctx, chart, opts.ChartValues)
 	if err != nil {
 		if !strings.HasPrefix(err.Error(), "cannot re-use a name that is still in use") {
 			return err
@@ -85,6 +88,34 @@ func Uninstall(opts UninstallOptions) error {
 	return nil
 }
 
+type GetOptions struct {
+	RESTConfig  *rest.Config
+	Namespace   string
+	ReleaseName string
+}
+
+// ReleaseManifest returns the manifest of the deployed release, empty when
+// the release does not exist.
+func ReleaseManifest(opts GetOptions) (string, error) {
+	rg := newRESTClientGetter(opts.RESTConfig, opts.Namespace)
+
+	actionConfig := new(action.Configuration)
+	err := actionConfig.Init(rg, opts.Namespace, helmDriver, func(string, ...interface{}) {})
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := action.NewGet(actionConfig).Run(opts.ReleaseName)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return rel.Manifest, nil
+}
+
 type restClientGetter struct {
 	Namespace string
 	config    *rest.Config
@@ -0,0 +1,186 @@
+package httputils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+)
+
+// retryBackoff is the delay before the first retry, doubled at each attempt.
+var retryBackoff = 500 * time.Millisecond
+
+var defaultClient, _ = NewClient(ClientOpts{})
+
+// ClientOpts configures the HTTP client shared by all lash downloads.
+type ClientOpts struct {
+	// Timeout to connect and to receive the response headers, for each
+	// attempt (defaults to 30s). The body read is bounded by the request
+	// context only, so that large downloads are not cut.
+	Timeout time.Duration
+	// MaxRetries for failed requests (network errors, 429 and 5xx).
+	MaxRetries int
+	HttpProxy  string
+	HttpsProxy string
+	NoProxy    string
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// AuthHeaders maps a host to the Authorization header sent to it.
+	AuthHeaders map[string]string
+}
+
+// Client returns the shared HTTP client.
+func Client() *http.Client {
+	return defaultClient
+}
+
+// Configure replaces the shared HTTP client.
+func Configure(opts ClientOpts) error {
+	c, err := NewClient(opts)
+	if err != nil {
+		return err
+	}
+
+	defaultClient = c
+	return nil
+}
+
+// NewClient returns an HTTP client with retries, proxy, custom CA
+// and authentication support.
+func NewClient(opts ClientOpts) (*http.Client, error) {
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = (&net.Dialer{
+		Timeout:   opts.Timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	tr.ResponseHeaderTimeout = opts.Timeout
+
+	if len(opts.HttpProxy) > 0 || len(opts.HttpsProxy) > 0 {
+		proxyFn := (&httpproxy.Config{
+			HTTPProxy:  opts.HttpProxy,
+			HTTPSProxy: opts.HttpsProxy,
+			NoProxy:    opts.NoProxy,
+		}).ProxyFunc()
+
+		tr.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFn(req.URL)
+		}
+	}
+
+	if len(opts.CAFile) > 0 {
+		pool, err := certPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	var rt http.RoundTripper = tr
+	if len(opts.AuthHeaders) > 0 {
+		rt = &authTransport{base: rt, headers: opts.AuthHeaders}
+	}
+
+	return &http.Client{
+		Transport: &retryTransport{
+			base:       rt,
+			maxRetries: opts.MaxRetries,
+		},
+	}, nil
+}
+
+func certPool(caFile string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in '%s'", caFile)
+	}
+
+	return pool, nil
+}
+
+type authTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	val, ok := t.headers[req.URL.Hostname()]
+	if !ok || len(req.Header.Get("Authorization")) > 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", val)
+
+	return t.base.RoundTrip(req)
+}
+
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.maxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			//nolint:errcheck
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(retryBackoff << attempt):
+		}
+	}
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	// only requests without body can be safely sent again
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+
+	if err != nil {
+		// certificate errors are not going to fix themselves
+		var certErr *tls.CertificateVerificationError
+		return req.Context().Err() == nil && !errors.As(err, &certErr)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
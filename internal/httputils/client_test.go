@@ -0,0 +1,128 @@
+package httputils
+
+import (
+	"bytes"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientRetries(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	assert.Nil(t, Configure(ClientOpts{MaxRetries: 2}), "expecting nil error configuring client")
+	defer Configure(ClientOpts{})
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, Fetch(srv.URL, buf), "expecting nil error after retries")
+	assert.Equal(t, "ok", buf.String())
+	assert.Equal(t, 3, hits)
+
+	hits = 0
+	assert.Nil(t, Configure(ClientOpts{MaxRetries: 1}), "expecting nil error configuring client")
+	assert.NotNil(t, Fetch(srv.URL, buf), "expecting error when retries are exhausted")
+	assert.Equal(t, 2, hits)
+}
+
+func TestClientTimeouts(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			// headers later than the timeout, the attempt is retried
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("o"))
+		w.(http.Flusher).Flush()
+		// the body read is not bounded by the timeout
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("k"))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOpts{Timeout: 100 * time.Millisecond})
+	assert.Nil(t, err, "expecting nil error creating client")
+
+	resp, err := c.Get(srv.URL)
+	assert.Nil(t, err, "expecting nil error after the retry")
+	defer resp.Body.Close()
+
+	dat, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err, "expecting nil error reading a slow body")
+	assert.Equal(t, "ok", string(dat))
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestClientAuthHeaders(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOpts{
+		AuthHeaders: map[string]string{"127.0.0.1": "Bearer s3cr3t"},
+	})
+	assert.Nil(t, err, "expecting nil error creating client")
+
+	resp, err := c.Get(srv.URL)
+	assert.Nil(t, err, "expecting nil error calling server")
+	resp.Body.Close()
+	assert.Equal(t, "Bearer s3cr3t", got)
+
+	c, err = NewClient(ClientOpts{
+		AuthHeaders: map[string]string{"registry.corp": "Bearer s3cr3t"},
+	})
+	assert.Nil(t, err, "expecting nil error creating client")
+
+	resp, err = c.Get(srv.URL)
+	assert.Nil(t, err, "expecting nil error calling server")
+	resp.Body.Close()
+	assert.Equal(t, "", got)
+}
+
+func TestClientCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOpts{MaxRetries: 1})
+	assert.Nil(t, err, "expecting nil error creating client")
+	_, err = c.Get(srv.URL)
+	assert.NotNil(t, err, "expecting unknown authority error")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	}), 0o644)
+	assert.Nil(t, err, "expecting nil error writing ca file")
+
+	c, err = NewClient(ClientOpts{CAFile: caFile})
+	assert.Nil(t, err, "expecting nil error creating client")
+
+	resp, err := c.Get(srv.URL)
+	assert.Nil(t, err, "expecting nil error with custom ca")
+	resp.Body.Close()
+}
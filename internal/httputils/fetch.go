@@ -1,15 +1,140 @@
 package httputils
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+
+	"github.com/platfornow/lash/internal/cache"
+)
+
+var (
+	// cacheLock guards the cache shared by the concurrent fetches (i.e.
+	// init fanned out to several contexts).
+	cacheLock    sync.Mutex
+	defaultCache *cache.Cache
+	// cacheLoader returns the cache on the first Fetch.
+	cacheLoader func() (*cache.Cache, error)
 )
 
+// SetCache sets the cache used by Fetch; nil disables caching.
+func SetCache(c *cache.Cache) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	defaultCache = c
+	cacheLoader = nil
+}
+
+// SetCacheLoader sets the func returning the cache used by Fetch; it is
+// called by the first Fetch, so commands that never download do not need
+// its settings.
+func SetCacheLoader(fn func() (*cache.Cache, error)) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	defaultCache = nil
+	cacheLoader = fn
+}
+
+func getCache() (*cache.Cache, error) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+
+	if cacheLoader != nil {
+		c, err := cacheLoader()
+		if err != nil {
+			return nil, err
+		}
+		defaultCache = c
+		cacheLoader = nil
+	}
+
+	return defaultCache, nil
+}
+
 // Fetch will download a url to a Writer.
+//
+// When a cache is set, fresh entries are served from disk, stale ones
+// are revalidated (ETag / If-Modified-Since) and, if the server cannot
+// be reached, the cached copy is used anyway.
 func Fetch(url string, wri io.Writer) error {
+	c, err := getCache()
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fetch(url, wri)
+	}
+
+	entry, data, err := c.Get(url)
+	if err != nil {
+		entry = nil
+	}
+
+	if c.Fresh(entry) {
+		_, err = wri.Write(data)
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	if entry != nil {
+		if len(entry.ETag) > 0 {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if len(entry.LastModified) > 0 {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := defaultClient.Do(req)
+	if err != nil {
+		// offline: fallback to the cached copy
+		if entry != nil {
+			_, err = wri.Write(data)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		//nolint:errcheck
+		c.Touch(url)
+		_, err = wri.Write(data)
+		return err
+
+	case resp.StatusCode >= http.StatusInternalServerError && entry != nil:
+		_, err = wri.Write(data)
+		return err
+
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("error fetching '%s' (status code: %d)", url, resp.StatusCode)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		return err
+	}
+
+	//nolint:errcheck
+	c.Put(cache.Entry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, buf.Bytes())
+
+	_, err = wri.Write(buf.Bytes())
+	return err
+}
+
+func fetch(url string, wri io.Writer) error {
 	// Get the data
-	resp, err := http.Get(url)
+	resp, err := defaultClient.Get(url)
 	if err != nil {
 		return err
 	}
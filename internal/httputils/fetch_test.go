@@ -0,0 +1,103 @@
+package httputils
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/platfornow/lash/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCached(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	hits, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("apiVersion: v1"))
+	}))
+
+	c := cache.New(t.TempDir(), time.Hour)
+	SetCache(c)
+	defer SetCache(nil)
+
+	url := srv.URL + "/index.yaml"
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, Fetch(url, buf), "expecting nil error fetching")
+	assert.Equal(t, "apiVersion: v1", buf.String())
+	assert.Equal(t, 1, hits)
+
+	// fresh entry, served from disk
+	buf.Reset()
+	assert.Nil(t, Fetch(url, buf), "expecting nil error fetching from cache")
+	assert.Equal(t, "apiVersion: v1", buf.String())
+	assert.Equal(t, 1, hits)
+
+	// stale entry, revalidated with the ETag
+	SetCache(cache.New(c.Dir(), 0))
+	buf.Reset()
+	assert.Nil(t, Fetch(url, buf), "expecting nil error revalidating")
+	assert.Equal(t, "apiVersion: v1", buf.String())
+	assert.Equal(t, 1, notModified)
+
+	// server is gone, fallback to the cached copy
+	srv.Close()
+	buf.Reset()
+	assert.Nil(t, Fetch(url, buf), "expecting nil error when offline")
+	assert.Equal(t, "apiVersion: v1", buf.String())
+
+	all, err := c.List()
+	assert.Nil(t, err, "expecting nil error listing cache")
+	assert.Equal(t, 1, len(all))
+	assert.Equal(t, url, all[0].URL)
+
+	assert.Nil(t, c.Clear(), "expecting nil error clearing cache")
+	all, err = c.List()
+	assert.Nil(t, err, "expecting nil error listing cache")
+	assert.Equal(t, 0, len(all))
+
+	buf.Reset()
+	assert.NotNil(t, Fetch(url, buf), "expecting error when offline without cache")
+}
+
+func TestFetchConcurrent(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("apiVersion: v1"))
+	}))
+	defer srv.Close()
+
+	loads := 0
+	SetCacheLoader(func() (*cache.Cache, error) {
+		loads++
+		return cache.New(t.TempDir(), time.Hour), nil
+	})
+	defer SetCache(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := &bytes.Buffer{}
+			assert.Nil(t, Fetch(srv.URL+"/index.yaml", buf), "expecting nil error fetching")
+			assert.Equal(t, "apiVersion: v1", buf.String())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, loads, "expecting the cache loaded once")
+}
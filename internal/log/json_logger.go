@@ -0,0 +1,273 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectRef references a Kubernetes object in a JSON log entry.
+type ObjectRef struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// Entry is a JSON log line.
+type Entry struct {
+	Time    time.Time   `json:"time"`
+	Level   string      `json:"level"`
+	Message string      `json:"message"`
+	Step    string      `json:"step,omitempty"`
+	Objects []ObjectRef `json:"objects,omitempty"`
+}
+
+var levelNames = map[logFunctionType]string{
+	debugFn: "debug",
+	infoFn:  "info",
+	warnFn:  "warn",
+	errorFn: "error",
+	fatalFn: "fatal",
+	panicFn: "panic",
+	doneFn:  "done",
+	failFn:  "fail",
+}
+
+// waitLevel is the level of the StartWait entries.
+const waitLevel = "wait"
+
+var ansiCodes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// jsonState is shared by a JSON logger and the loggers returned by its
+// WithObjects.
+type jsonState struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+	// step is the last wait message
+	step string
+	// buf holds the raw output until a newline
+	buf bytes.Buffer
+
+	now func() time.Time
+}
+
+// jsonLogger writes a JSON entry per message line; wait messages are the
+// step of the following entries.
+type jsonLogger struct {
+	state   *jsonState
+	objects []ObjectRef
+
+	fileLogger *jsonLogger
+}
+
+// NewJSONLogger returns a logger writing JSON lines to w.
+func NewJSONLogger(w io.Writer, level Level) Logger {
+	return newJSONLogger(w, level)
+}
+
+func newJSONLogger(w io.Writer, level Level) *jsonLogger {
+	return &jsonLogger{
+		state: &jsonState{w: w, level: level, now: time.Now},
+	}
+}
+
+// WithObjects returns a logger adding the object references to the entries.
+func (j *jsonLogger) WithObjects(refs ...ObjectRef) Logger {
+	res := &jsonLogger{
+		state:   j.state,
+		objects: append(append([]ObjectRef{}, j.objects...), refs...),
+	}
+	if j.fileLogger != nil {
+		res.fileLogger = j.fileLogger.WithObjects(refs...).(*jsonLogger)
+	}
+	return res
+}
+
+//nolint:errcheck
+func (j *jsonLogger) encode(level, message string) {
+	s := j.state
+
+	sc := strings.Split(strings.TrimSuffix(ansiCodes.ReplaceAllString(message, ""), "\n"), "\n")
+	for _, line := range sc {
+		dat, err := json.Marshal(Entry{
+			Time:    s.now().UTC(),
+			Level:   level,
+			Message: line,
+			Step:    s.step,
+			Objects: j.objects,
+		})
+		if err != nil {
+			continue
+		}
+		s.w.Write(append(dat, '\n'))
+	}
+}
+
+func (j *jsonLogger) writeMessage(fnType logFunctionType, message string) {
+	if j.fileLogger != nil {
+		j.fileLogger.writeMessage(fnType, message)
+	}
+
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+
+	if j.state.level >= fnTypeInformationMap[fnType].logLevel {
+		j.encode(levelNames[fnType], message)
+	}
+}
+
+func (j *jsonLogger) StartWait(message string) {
+	if j.fileLogger != nil {
+		j.fileLogger.StartWait(message)
+	}
+
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+
+	// progress updates repeat the same message
+	if message == j.state.step {
+		return
+	}
+	j.state.step = message
+
+	if j.state.level >= InfoLevel {
+		j.encode(waitLevel, message)
+	}
+}
+
+func (j *jsonLogger) StopWait() {}
+
+func (j *jsonLogger) Debug(args ...interface{}) {
+	j.writeMessage(debugFn, fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Debugf(format string, args ...interface{}) {
+	j.writeMessage(debugFn, fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Info(args ...interface{}) {
+	j.writeMessage(infoFn, fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Infof(format string, args ...interface{}) {
+	j.writeMessage(infoFn, fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Warn(args ...interface{}) {
+	j.writeMessage(warnFn, fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Warnf(format string, args ...interface{}) {
+	j.writeMessage(warnFn, fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Error(args ...interface{}) {
+	j.writeMessage(errorFn, fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Errorf(format string, args ...interface{}) {
+	j.writeMessage(errorFn, fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Fatal(args ...interface{}) {
+	j.writeMessage(fatalFn, fmt.Sprintln(args...))
+	os.Exit(1)
+}
+
+func (j *jsonLogger) Fatalf(format string, args ...interface{}) {
+	j.writeMessage(fatalFn, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (j *jsonLogger) Panic(args ...interface{}) {
+	j.writeMessage(panicFn, fmt.Sprintln(args...))
+	panic(fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Panicf(format string, args ...interface{}) {
+	j.writeMessage(panicFn, fmt.Sprintf(format, args...))
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Done(args ...interface{}) {
+	j.writeMessage(doneFn, fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Donef(format string, args ...interface{}) {
+	j.writeMessage(doneFn, fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Fail(args ...interface{}) {
+	j.writeMessage(failFn, fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Failf(format string, args ...interface{}) {
+	j.writeMessage(failFn, fmt.Sprintf(format, args...))
+}
+
+func (j *jsonLogger) Print(level Level, args ...interface{}) {
+	j.Printf(level, "%s", fmt.Sprintln(args...))
+}
+
+func (j *jsonLogger) Printf(level Level, format string, args ...interface{}) {
+	for fnType, el := range fnTypeInformationMap {
+		if el.logLevel == level && fnType != doneFn && fnType != failFn {
+			j.writeMessage(fnType, fmt.Sprintf(format, args...))
+			return
+		}
+	}
+}
+
+// Write buffers the raw output (i.e. tables written in fragments) and
+// writes an info entry per complete line.
+func (j *jsonLogger) Write(message []byte) (int, error) {
+	if j.fileLogger != nil {
+		//nolint:errcheck
+		j.fileLogger.Write(message)
+	}
+
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+
+	s := j.state
+	s.buf.Write(message)
+	for {
+		idx := bytes.IndexByte(s.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(s.buf.Next(idx + 1))
+		if s.level >= InfoLevel && len(strings.TrimSpace(line)) > 0 {
+			j.encode(levelNames[infoFn], strings.TrimRight(line, " \n"))
+		}
+	}
+
+	return len(message), nil
+}
+
+//nolint:errcheck
+func (j *jsonLogger) WriteString(message string) {
+	j.Write([]byte(message))
+}
+
+func (j *jsonLogger) SetLevel(level Level) {
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+
+	j.state.level = level
+}
+
+func (j *jsonLogger) GetLevel() Level {
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+
+	return j.state.level
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func entries(t *testing.T, buf *bytes.Buffer) []Entry {
+	res := []Entry{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var el Entry
+		if err := json.Unmarshal([]byte(line), &el); err != nil {
+			t.Fatalf("invalid JSON line %q: %s", line, err)
+		}
+		res = append(res, el)
+	}
+	return res
+}
+
+func TestJSONLogger(t *testing.T) {
+	buf := bytes.Buffer{}
+	file := bytes.Buffer{}
+
+	l := newJSONLogger(&buf, InfoLevel)
+	l.fileLogger = newJSONLogger(&file, DebugLevel)
+	l.state.now = func() time.Time { return time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC) }
+
+	l.StartWait("installing provider helm...")
+	l.StartWait("installing provider helm...")
+	l.Debug("retrieved manifest")
+	WithObjects(l, ObjectRef{Kind: "Provider", Name: "provider-helm"}).Done("provider installed")
+	l.Write([]byte("\x1b[32m NAME \x1b[0m"))
+	l.Write([]byte(" READY\n"))
+
+	got := entries(t, &buf)
+	if len(got) != 3 {
+		t.Fatalf("expected wait, done and output entries, got: %+v", got)
+	}
+
+	if got[0].Level != "wait" || got[0].Message != "installing provider helm..." {
+		t.Fatalf("unexpected wait entry: %+v", got[0])
+	}
+
+	done := got[1]
+	if done.Level != "done" || done.Step != "installing provider helm..." || len(done.Objects) != 1 || done.Objects[0].Name != "provider-helm" {
+		t.Fatalf("unexpected done entry: %+v", done)
+	}
+	if !done.Time.Equal(time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %s", done.Time)
+	}
+
+	if got[2].Message != " NAME  READY" {
+		t.Fatalf("expected the raw output line without colors, got: %q", got[2].Message)
+	}
+
+	// the file gets the debug messages too
+	if all := entries(t, &file); len(all) != 4 || all[1].Level != "debug" {
+		t.Fatalf("unexpected file entries: %+v", all)
+	}
+}
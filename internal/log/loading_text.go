@@ -1,8 +1,9 @@
 package log
 
 import (
-	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mgutz/ansi"
@@ -27,11 +28,20 @@ type loadingText struct {
 	loadingRune int
 	isShown     bool
 	stopChan    chan bool
+
+	// mu guards Message, updated while rendering
+	mu sync.Mutex
+	// width of the last rendered line
+	width int
 }
 
+// Start shows the spinner; the elapsed time counts from the first Start,
+// so it is kept when the spinner is hidden to print other messages.
 func (l *loadingText) Start() {
 	l.isShown = false
-	l.StartTimestamp = time.Now().UnixNano()
+	if l.StartTimestamp == 0 {
+		l.StartTimestamp = time.Now().UnixNano()
+	}
 
 	if l.stopChan == nil {
 		l.stopChan = make(chan bool)
@@ -51,6 +61,21 @@ func (l *loadingText) Start() {
 	}()
 }
 
+// SetMessage replaces the message keeping the elapsed time.
+func (l *loadingText) SetMessage(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.Message = message
+}
+
+func (l *loadingText) getMessage() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.Message
+}
+
 func (l *loadingText) getLoadingChar() string {
 	max := len(spinnerFrames)
 	idx := l.loadingRune % max
@@ -75,9 +100,8 @@ func (l *loadingText) render() {
 
 	l.Stream.Write([]byte(ansi.Color(string(messagePrefix), "cyan+b")))
 
-	timeElapsed := fmt.Sprintf("%d", (time.Now().UnixNano()-l.StartTimestamp)/int64(time.Second))
-	message := []byte(l.getLoadingChar() + " " + l.Message)
-	messageSuffix := " (" + timeElapsed + "s)"
+	message := []byte(l.getLoadingChar() + " " + l.getMessage())
+	messageSuffix := " (" + formatElapsed(time.Duration(time.Now().UnixNano()-l.StartTimestamp)) + ")"
 	prefixLength := len(messagePrefix)
 	suffixLength := len(messageSuffix)
 
@@ -92,15 +116,31 @@ func (l *loadingText) render() {
 	}
 
 	message = append(message, messageSuffix...)
+
+	// clear what is left of a longer previous message
+	width := len(message)
+	if width < l.width {
+		message = append(message, strings.Repeat(" ", l.width-width)...)
+	}
+	l.width = width
+
 	l.Stream.Write(message)
 }
 
+// formatElapsed returns d in seconds, i.e. 42s or 2m5s.
+func formatElapsed(d time.Duration) string {
+	return d.Truncate(time.Second).String()
+}
+
 //nolint:errcheck
 func (l *loadingText) Stop() {
 	l.stopChan <- true
 	l.Stream.Write([]byte("\r"))
 
-	messageLength := len(l.Message) + 20
+	messageLength := len(l.getMessage()) + 20
+	if l.width+10 > messageLength {
+		messageLength = l.width + 10
+	}
 
 	for i := 0; i < messageLength; i++ {
 		l.Stream.Write([]byte(" "))
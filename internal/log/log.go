@@ -2,6 +2,8 @@
 package log
 
 import (
+	"io"
+	"os"
 	"strings"
 
 	"github.com/mgutz/ansi"
@@ -21,6 +23,39 @@ func SetInstance(logger Logger) {
 	defaultLog = logger
 }
 
+// Configure replaces the default logger: JSON lines on stdout when json is
+// true, colored messages and spinners otherwise. When file is set all the
+// messages, debug included, are written there too as JSON lines.
+func Configure(json bool, file io.Writer) {
+	level := defaultLog.GetLevel()
+
+	var fl *jsonLogger
+	if file != nil {
+		fl = newJSONLogger(file, DebugLevel)
+	}
+
+	if json {
+		jl := newJSONLogger(os.Stdout, level)
+		jl.fileLogger = fl
+		defaultLog = jl
+		return
+	}
+
+	defaultLog = &stdoutLogger{
+		level:      level,
+		fileLogger: fl,
+	}
+}
+
+// WithObjects returns l adding the object references to its entries when it
+// writes JSON lines, l otherwise.
+func WithObjects(l Logger, refs ...ObjectRef) Logger {
+	if jl, ok := l.(*jsonLogger); ok {
+		return jl.WithObjects(refs...)
+	}
+	return l
+}
+
 // WriteColored writes a message in color
 func writeColored(message string, color string) {
 	defaultLog.Write([]byte(ansi.Color(message, color)))
@@ -0,0 +1,136 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefixLogger writes through another logger prefixing each message line; wait
+// messages are printed as info lines, when they change, since more prefixed
+// loggers share the same terminal and cannot animate concurrently.
+type prefixLogger struct {
+	base   Logger
+	prefix string
+
+	mu sync.Mutex
+	// wait is the last wait message
+	wait string
+}
+
+// NewPrefixLogger returns a logger writing to base with "[prefix] " before each message.
+func NewPrefixLogger(base Logger, prefix string) Logger {
+	return &prefixLogger{
+		base:   base,
+		prefix: fmt.Sprintf("[%s] ", prefix),
+	}
+}
+
+func (p *prefixLogger) lines(message string) string {
+	message = strings.TrimSuffix(message, "\n")
+	return p.prefix + strings.ReplaceAll(message, "\n", "\n"+p.prefix)
+}
+
+func (p *prefixLogger) Debug(args ...interface{}) {
+	p.base.Debug(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Debugf(format string, args ...interface{}) {
+	p.base.Debug(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Info(args ...interface{}) {
+	p.base.Info(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Infof(format string, args ...interface{}) {
+	p.base.Info(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Warn(args ...interface{}) {
+	p.base.Warn(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Warnf(format string, args ...interface{}) {
+	p.base.Warn(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Error(args ...interface{}) {
+	p.base.Error(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Errorf(format string, args ...interface{}) {
+	p.base.Error(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Fatal(args ...interface{}) {
+	p.base.Fatal(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Fatalf(format string, args ...interface{}) {
+	p.base.Fatal(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Panic(args ...interface{}) {
+	p.base.Panic(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Panicf(format string, args ...interface{}) {
+	p.base.Panic(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Done(args ...interface{}) {
+	p.base.Done(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Donef(format string, args ...interface{}) {
+	p.base.Done(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) Fail(args ...interface{}) {
+	p.base.Fail(p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Failf(format string, args ...interface{}) {
+	p.base.Fail(p.lines(fmt.Sprintf(format, args...)))
+}
+
+func (p *prefixLogger) StartWait(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// progress updates repeat the same message
+	if message == p.wait {
+		return
+	}
+	p.wait = message
+
+	p.base.Info(p.lines(message))
+}
+
+func (p *prefixLogger) StopWait() {}
+
+func (p *prefixLogger) Print(level Level, args ...interface{}) {
+	p.base.Print(level, p.lines(fmt.Sprint(args...)))
+}
+
+func (p *prefixLogger) Printf(level Level, format string, args ...interface{}) {
+	p.base.Print(level, p.lines(fmt.Sprintf(format, args...)))
+}
+
+// Write is not prefixed: raw output (i.e. tables) is written in fragments.
+func (p *prefixLogger) Write(message []byte) (int, error) {
+	return p.base.Write(message)
+}
+
+func (p *prefixLogger) WriteString(message string) {
+	p.base.WriteString(message)
+}
+
+func (p *prefixLogger) SetLevel(level Level) {
+	p.base.SetLevel(level)
+}
+
+func (p *prefixLogger) GetLevel() Level {
+	return p.base.GetLevel()
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrefixLoggerStartWait(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := NewPrefixLogger(newJSONLogger(&buf, InfoLevel), "staging")
+
+	l.StartWait("waiting for Core/core readiness (3/7 resources ready)...")
+	l.StartWait("waiting for Core/core readiness (3/7 resources ready)...")
+	l.StartWait("waiting for Core/core readiness (5/7 resources ready)...")
+	l.StartWait("waiting for Core/core readiness (5/7 resources ready)...")
+
+	got := entries(t, &buf)
+	if len(got) != 2 {
+		t.Fatalf("expected a line per changed wait message, got: %+v", got)
+	}
+	if got[1].Message != "[staging] waiting for Core/core readiness (5/7 resources ready)..." {
+		t.Fatalf("unexpected wait line: %+v", got[1])
+	}
+}
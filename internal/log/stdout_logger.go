@@ -23,7 +23,8 @@ type stdoutLogger struct {
 
 	loadingText *loadingText
 
-	//	fileLogger Logger
+	// fileLogger, if set, gets all the messages whatever the level
+	fileLogger *jsonLogger
 }
 
 type fnTypeInformation struct {
@@ -86,6 +87,10 @@ var fnTypeInformationMap = map[logFunctionType]*fnTypeInformation{
 
 //nolint:errcheck
 func (s *stdoutLogger) writeMessage(fnType logFunctionType, message string) {
+	if s.fileLogger != nil {
+		s.fileLogger.writeMessage(fnType, message)
+	}
+
 	fnInformation := fnTypeInformationMap[fnType]
 	if s.level >= fnInformation.logLevel {
 		if s.loadingText != nil {
@@ -104,18 +109,19 @@ func (s *stdoutLogger) writeMessage(fnType logFunctionType, message string) {
 	}
 }
 
-// StartWait prints a wait message until StopWait is called
+// StartWait prints a wait message until StopWait is called; while waiting
+// a new message replaces the current one keeping the elapsed time.
 func (s *stdoutLogger) StartWait(message string) {
 	s.logMutex.Lock()
 	defer s.logMutex.Unlock()
 
-	if s.loadingText != nil {
-		if s.loadingText.Message == message {
-			return
-		}
+	if s.fileLogger != nil {
+		s.fileLogger.StartWait(message)
+	}
 
-		s.loadingText.Stop()
-		s.loadingText = nil
+	if s.loadingText != nil {
+		s.loadingText.SetMessage(message)
+		return
 	}
 
 	if s.level >= InfoLevel {
@@ -312,10 +318,15 @@ func (s *stdoutLogger) GetLevel() Level {
 	return s.level
 }
 
+//nolint:errcheck
 func (s *stdoutLogger) Write(message []byte) (int, error) {
 	s.logMutex.Lock()
 	defer s.logMutex.Unlock()
 
+	if s.fileLogger != nil {
+		s.fileLogger.Write(message)
+	}
+
 	if s.level >= InfoLevel {
 		if s.loadingText != nil {
 			s.loadingText.Stop()
@@ -338,6 +349,10 @@ func (s *stdoutLogger) WriteString(message string) {
 	s.logMutex.Lock()
 	defer s.logMutex.Unlock()
 
+	if s.fileLogger != nil {
+		s.fileLogger.WriteString(message)
+	}
+
 	if s.level >= InfoLevel {
 		if s.loadingText != nil {
 			s.loadingText.Stop()
@@ -0,0 +1,260 @@
+// Package notify posts the progress of the installations to webhooks: a
+// message when a step starts, completes or fails and a final summary.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+	"github.com/platfornow/lash/internal/httputils"
+)
+
+// Payload formats.
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 10 * time.Second
+)
+
+// retryBackoff is the delay before the first retry, doubled at each attempt.
+var retryBackoff = time.Second
+
+// gracePeriod bounds the posts once the context of the command is done, so
+// that an interrupted command still reports its failure without waiting
+// for the retries.
+var gracePeriod = 3 * time.Second
+
+// Opts configures a webhook.
+type Opts struct {
+	URL string
+	// Format of the payloads: FormatJSON (default), FormatSlack or FormatTeams.
+	Format  string
+	Headers map[string]string
+	// Retries of a failed post (network errors, 429 and 5xx), defaults to 3.
+	Retries int
+	// Title names the run in the messages (i.e. "lash init staging").
+	Title string
+	// Client defaults to the shared lash HTTP client.
+	Client *http.Client
+	// Context of the command, the posts are given gracePeriod once it is
+	// done; defaults to context.Background().
+	Context context.Context
+}
+
+// Notifier posts the step events of a bus to a webhook and keeps the steps
+// outcome for the summary.
+type Notifier struct {
+	opts  Opts
+	start time.Time
+	now   func() time.Time
+
+	mu    sync.Mutex
+	steps []Step
+	// errs are the failed posts
+	errs []error
+}
+
+// New returns a notifier for the webhook.
+func New(opts Opts) (*Notifier, error) {
+	if len(opts.URL) == 0 {
+		return nil, errors.New("webhook url is required")
+	}
+
+	switch opts.Format {
+	case "":
+		opts.Format = FormatJSON
+	case FormatJSON, FormatSlack, FormatTeams:
+	default:
+		return nil, fmt.Errorf("invalid webhook format '%s' (%s, %s, %s)", opts.Format, FormatJSON, FormatSlack, FormatTeams)
+	}
+
+	if opts.Retries <= 0 {
+		opts.Retries = defaultRetries
+	}
+	if opts.Client == nil {
+		opts.Client = httputils.Client()
+	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+
+	return &Notifier{opts: opts, start: time.Now(), now: time.Now}, nil
+}
+
+// Handle posts the StepStarted, StepCompleted and Failure events; subscribe
+// it with SubscribeAsync so that slow webhooks do not hold the command.
+func (n *Notifier) Handle(e eventbus.Event) {
+	var msg *Message
+
+	switch evt := e.(type) {
+	case *events.StepStarted:
+		msg = &Message{Event: string(evt.EventID()), Phase: evt.Phase, Status: StatusStarted,
+			Text: fmt.Sprintf("%s: %s started", n.opts.Title, evt.Phase)}
+
+	case *events.StepCompleted:
+		n.addStep(Step{Phase: evt.Phase, Status: StatusSucceeded, Duration: evt.Duration})
+		msg = &Message{Event: string(evt.EventID()), Phase: evt.Phase, Status: StatusSucceeded, Duration: evt.Duration,
+			Text: fmt.Sprintf("%s: %s", n.opts.Title, evt)}
+
+	case *events.Failure:
+		n.addStep(Step{Phase: evt.Phase, Status: StatusFailed, Error: errorText(evt.Err)})
+		msg = &Message{Event: string(evt.EventID()), Phase: evt.Phase, Status: StatusFailed, Error: errorText(evt.Err),
+			Text: fmt.Sprintf("%s: %s", n.opts.Title, evt)}
+
+	default:
+		return
+	}
+
+	msg.Title = n.opts.Title
+	msg.Time = n.now().UTC()
+	n.post(msg)
+}
+
+// Summary posts the outcome of the run, err is the error of the command.
+// The returned error reports the posts that failed.
+func (n *Notifier) Summary(err error) error {
+	n.mu.Lock()
+	steps := append([]Step{}, n.steps...)
+	n.mu.Unlock()
+
+	msg := &Message{
+		Event:    "summary",
+		Title:    n.opts.Title,
+		Time:     n.now().UTC(),
+		Status:   StatusSucceeded,
+		Duration: n.now().Sub(n.start),
+		Steps:    steps,
+	}
+	if err != nil {
+		msg.Status = StatusFailed
+		msg.Error = err.Error()
+	}
+
+	lines := []string{fmt.Sprintf("%s %s in %s", n.opts.Title, msg.Status, msg.Duration.Round(time.Second))}
+	for _, el := range steps {
+		lines = append(lines, "- "+el.String())
+	}
+	if err != nil {
+		lines = append(lines, "error: "+err.Error())
+	}
+	msg.Text = strings.Join(lines, "\n")
+
+	n.post(msg)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return errors.Join(n.errs...)
+}
+
+func (n *Notifier) addStep(s Step) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.steps = append(n.steps, s)
+}
+
+// post sends the message, retrying on network errors, 429 and 5xx.
+func (n *Notifier) post(msg *Message) {
+	dat, err := encode(n.opts.Format, msg)
+	if err != nil {
+		n.fail(err)
+		return
+	}
+
+	ctx, cancel := graceContext(n.opts.Context)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		retry, err := n.send(ctx, dat)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= n.opts.Retries {
+			n.fail(fmt.Errorf("posting %s to webhook: %w", msg.Event, err))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			n.fail(ctx.Err())
+			return
+		case <-time.After(retryBackoff << attempt):
+		}
+	}
+}
+
+// send posts the payload once, retry tells whether the error is temporary.
+func (n *Notifier) send(ctx context.Context, dat []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.opts.URL, bytes.NewReader(dat))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.opts.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	//nolint:errcheck
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// graceContext returns a context cancelled gracePeriod after ctx is done.
+func graceContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	res, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-res.Done():
+			return
+		}
+
+		select {
+		case <-time.After(gracePeriod):
+			cancel()
+		case <-res.Done():
+		}
+	}()
+
+	return res, cancel
+}
+
+func (n *Notifier) fail(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.errs = append(n.errs, err)
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
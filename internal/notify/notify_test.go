@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/platfornow/lash/internal/eventbus"
+	"github.com/platfornow/lash/internal/events"
+)
+
+// receiver records the posted payloads, failing the first attempts.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	bodies   []string
+	headers  http.Header
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts++
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	dat, _ := io.ReadAll(req.Body)
+	r.bodies = append(r.bodies, string(dat))
+	r.headers = req.Header.Clone()
+}
+
+func TestNotifier(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	rcv := &receiver{failures: 2}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	n, err := New(Opts{
+		URL:     srv.URL,
+		Headers: map[string]string{"X-Token": "secret"},
+		Title:   "lash init",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := eventbus.New()
+	bus.SubscribeAsync("step:*", n.Handle, 10)
+
+	bus.Publish(events.NewStepStarted("providers", nil))
+	bus.Publish(events.NewDebugEvent("not posted"))
+	bus.Publish(events.NewStepCompleted("providers", 2*time.Second))
+	bus.Publish(events.NewStepStarted("claims", nil))
+	bus.Publish(events.NewFailure("claims", errors.New("core not ready")))
+	bus.Close()
+
+	if err := n.Summary(errors.New("core not ready")); err != nil {
+		t.Fatal(err)
+	}
+
+	if rcv.attempts != 7 || len(rcv.bodies) != 5 {
+		t.Fatalf("expected 5 posts after 2 retries, got %d attempts: %v", rcv.attempts, rcv.bodies)
+	}
+	if rcv.headers.Get("X-Token") != "secret" || rcv.headers.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", rcv.headers)
+	}
+
+	msgs := []Message{}
+	for _, el := range rcv.bodies {
+		var msg Message
+		if err := json.Unmarshal([]byte(el), &msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if msgs[0].Event != "step:started" || msgs[0].Phase != "providers" || msgs[0].Text != "lash init: providers started" {
+		t.Fatalf("unexpected started message: %+v", msgs[0])
+	}
+	if msgs[1].Status != StatusSucceeded || msgs[1].Duration != 2*time.Second {
+		t.Fatalf("unexpected completed message: %+v", msgs[1])
+	}
+	if msgs[3].Status != StatusFailed || msgs[3].Error != "core not ready" {
+		t.Fatalf("unexpected failure message: %+v", msgs[3])
+	}
+
+	summary := msgs[4]
+	if summary.Event != "summary" || summary.Status != StatusFailed || len(summary.Steps) != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	for _, want := range []string{"lash init failed in", "- providers succeeded in 2s", "- claims failed: core not ready"} {
+		if !strings.Contains(summary.Text, want) {
+			t.Fatalf("expected %q in the summary:\n%s", want, summary.Text)
+		}
+	}
+}
+
+func TestNotifierGivesUp(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	rcv := &receiver{failures: 10}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	n, err := New(Opts{URL: srv.URL, Retries: 2, Title: "lash uninstall"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.Summary(nil)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected the post error, got: %v", err)
+	}
+	if rcv.attempts != 3 {
+		t.Fatalf("expected 3 attempts, got: %d", rcv.attempts)
+	}
+}
+
+func TestNotifierCancelled(t *testing.T) {
+	retryBackoff = time.Hour
+	gracePeriod = 10 * time.Millisecond
+	defer func() { gracePeriod = 3 * time.Second }()
+
+	rcv := &receiver{failures: 10}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	n, err := New(Opts{URL: srv.URL, Title: "lash init", Context: ctx})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- n.Summary(ctx.Err())
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the post to be cancelled, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Summary keeps retrying after the command is cancelled")
+	}
+	if rcv.attempts != 1 {
+		t.Fatalf("expected 1 attempt, got: %d", rcv.attempts)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	msg := &Message{Title: "lash init", Status: StatusSucceeded, Text: "lash init succeeded in 5m0s\n- providers succeeded in 2s"}
+
+	dat, err := encode(FormatSlack, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(dat) != `{"text":"lash init succeeded in 5m0s\n- providers succeeded in 2s"}` {
+		t.Fatalf("unexpected slack payload: %s", dat)
+	}
+
+	dat, err = encode(FormatTeams, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var card map[string]string
+	if err := json.Unmarshal(dat, &card); err != nil {
+		t.Fatal(err)
+	}
+	if card["@type"] != "MessageCard" || card["themeColor"] != "2EB886" || card["title"] != "lash init" ||
+		!strings.Contains(card["text"], "5m0s  \n- providers") {
+		t.Fatalf("unexpected teams payload: %s", dat)
+	}
+
+	if _, err := New(Opts{URL: "http://example.com", Format: "xml"}); err == nil {
+		t.Fatal("expected invalid format error")
+	}
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Statuses of the steps and of the run.
+const (
+	StatusStarted   = "started"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Step is the outcome of a step reported in the summary.
+type Step struct {
+	Phase    string        `json:"phase"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// String returns the phase with its duration or error.
+func (s Step) String() string {
+	if s.Status == StatusFailed {
+		return fmt.Sprintf("%s failed: %s", s.Phase, s.Error)
+	}
+	return fmt.Sprintf("%s %s in %s", s.Phase, s.Status, s.Duration.Round(time.Millisecond))
+}
+
+// Message is the generic JSON payload; Text is the human readable message
+// posted to Slack and Teams.
+type Message struct {
+	// Event is the id of the step event or "summary".
+	Event    string        `json:"event"`
+	Title    string        `json:"title"`
+	Time     time.Time     `json:"time"`
+	Phase    string        `json:"phase,omitempty"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Steps    []Step        `json:"steps,omitempty"`
+	Text     string        `json:"text"`
+}
+
+// slackMessage is the payload of the Slack incoming webhooks.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// teamsMessage is the MessageCard payload of the MS Teams incoming webhooks.
+type teamsMessage struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	Summary    string `json:"summary"`
+	ThemeColor string `json:"themeColor,omitempty"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+// themeColors are the Teams card colors by status.
+var themeColors = map[string]string{
+	StatusStarted:   "0078D7",
+	StatusSucceeded: "2EB886",
+	StatusFailed:    "D40E0D",
+}
+
+func encode(format string, msg *Message) ([]byte, error) {
+	switch format {
+	case FormatSlack:
+		return json.Marshal(slackMessage{Text: msg.Text})
+
+	case FormatTeams:
+		return json.Marshal(teamsMessage{
+			Type:       "MessageCard",
+			Context:    "https://schema.org/extensions",
+			Summary:    msg.Title,
+			ThemeColor: themeColors[msg.Status],
+			Title:      msg.Title,
+			// Teams renders the text as markdown, line breaks need two spaces
+			Text: strings.ReplaceAll(msg.Text, "\n", "  \n"),
+		})
+
+	default:
+		return json.Marshal(msg)
+	}
+}
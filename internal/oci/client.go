@@ -0,0 +1,409 @@
+// Package oci talks to OCI (docker) registries through the distribution API:
+// it resolves package tags to digests, checks that images exist and copies
+// images between registries.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/platfornow/lash/internal/httputils"
+)
+
+const (
+	MediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	digestHeader = "Docker-Content-Digest"
+)
+
+var acceptedManifests = strings.Join([]string{
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifest,
+	MediaTypeDockerList,
+}, ", ")
+
+// ErrNotFound is returned when the image does not exist in the registry.
+var ErrNotFound = errors.New("image not found")
+
+// Client is a minimal registry client. Requests go through the shared lash
+// HTTP client, so proxy, CA and per-host authorization headers apply.
+type Client struct {
+	// PlainHTTP talks to registries without TLS; registries on localhost
+	// are always reached over plain HTTP.
+	PlainHTTP bool
+
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewClient returns a registry client using the shared HTTP client.
+func NewClient() *Client {
+	return &Client{
+		httpClient: httputils.Client(),
+		tokens:     map[string]string{},
+	}
+}
+
+// Resolve returns the digest of the manifest the reference points to.
+func (c *Client) Resolve(ctx context.Context, ref Reference) (string, error) {
+	resp, err := c.do(ctx, ref, http.MethodHead, manifestPath(ref, ref.identifier()), nil, "", "pull")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if dgst := resp.Header.Get(digestHeader); len(dgst) > 0 {
+		return dgst, nil
+	}
+
+	// some registries omit the digest header on HEAD requests
+	_, _, dgst, err := c.manifest(ctx, ref, ref.identifier())
+	return dgst, err
+}
+
+// Exists reports whether the image exists in the registry.
+func (c *Client) Exists(ctx context.Context, ref Reference) (bool, error) {
+	_, err := c.Resolve(ctx, ref)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Copy copies the image (with all the manifests of an index and their
+// blobs) from src to dst and returns the digest of the copied manifest.
+func (c *Client) Copy(ctx context.Context, src, dst Reference) (string, error) {
+	return c.copyManifest(ctx, src, dst, src.identifier(), dst.identifier())
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    *descriptor  `json:"config,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+func (c *Client) copyManifest(ctx context.Context, src, dst Reference, srcID, dstID string) (string, error) {
+	data, mediaType, dgst, err := c.manifest(ctx, src, srcID)
+	if err != nil {
+		return "", err
+	}
+
+	m := manifest{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", fmt.Errorf("decoding manifest of '%s': %w", src, err)
+	}
+
+	for _, el := range m.Manifests {
+		if _, err := c.copyManifest(ctx, src, dst, el.Digest, el.Digest); err != nil {
+			return "", err
+		}
+	}
+
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = append([]descriptor{*m.Config}, blobs...)
+	}
+	for _, el := range blobs {
+		if err := c.copyBlob(ctx, src, dst, el.Digest); err != nil {
+			return "", err
+		}
+	}
+
+	resp, err := c.do(ctx, dst, http.MethodPut, manifestPath(dst, dstID), bytes.NewReader(data), mediaType, "pull,push")
+	if err != nil {
+		return "", fmt.Errorf("pushing manifest to '%s': %w", dst, err)
+	}
+	resp.Body.Close()
+
+	return dgst, nil
+}
+
+func (c *Client) copyBlob(ctx context.Context, src, dst Reference, dgst string) error {
+	resp, err := c.do(ctx, dst, http.MethodHead, blobPath(dst, dgst), nil, "", "pull,push")
+	if err == nil {
+		resp.Body.Close()
+		return nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	resp, err = c.do(ctx, dst, http.MethodPost, fmt.Sprintf("/v2/%s/blobs/uploads/", dst.Repository), nil, "", "pull,push")
+	if err != nil {
+		return fmt.Errorf("starting upload to '%s': %w", dst.Name(), err)
+	}
+	resp.Body.Close()
+
+	// the upload location can be relative or point to another host
+	loc, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+	q := loc.Query()
+	q.Set("digest", dgst)
+	loc.RawQuery = q.Encode()
+
+	blob, err := c.do(ctx, src, http.MethodGet, blobPath(src, dgst), nil, "", "pull")
+	if err != nil {
+		return fmt.Errorf("fetching blob '%s' from '%s': %w", dgst, src.Name(), err)
+	}
+	defer blob.Body.Close()
+
+	// the layer is streamed from the source to the destination
+	body := &sizedReader{Reader: blob.Body, size: blob.ContentLength}
+	resp, err = c.do(ctx, dst, http.MethodPut, loc.String(), body, "application/octet-stream", "pull,push")
+	if err != nil {
+		return fmt.Errorf("uploading blob '%s' to '%s': %w", dgst, dst.Name(), err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// sizedReader is a streamed request body, size is -1 when unknown.
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// manifest returns the manifest content, its media type and its digest.
+func (c *Client) manifest(ctx context.Context, ref Reference, id string) ([]byte, string, string, error) {
+	resp, err := c.do(ctx, ref, http.MethodGet, manifestPath(ref, id), nil, "", "pull")
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	dgst := resp.Header.Get(digestHeader)
+	if len(dgst) == 0 {
+		sum := sha256.Sum256(data)
+		dgst = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return data, resp.Header.Get("Content-Type"), dgst, nil
+}
+
+// do sends the request to path, or to target when it is an absolute URL,
+// authenticating with a bearer token when the registry asks for it; non
+// 2xx responses are returned as errors. A streamed body is sent once.
+func (c *Client) do(ctx context.Context, ref Reference, method, target string, body io.Reader, contentType, actions string) (*http.Response, error) {
+	scope := fmt.Sprintf("repository:%s:%s", ref.Repository, actions)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if sk, ok := body.(io.Seeker); ok && attempt > 0 {
+			if _, err := sk.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := c.newRequest(ctx, ref.Registry, method, target, body, contentType)
+		if err != nil {
+			return nil, err
+		}
+
+		// the token is not sent to other hosts (i.e. blob storage)
+		if tok := c.token(ref.Registry, scope); len(tok) > 0 && req.URL.Host == ref.Registry {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		_, replayable := body.(io.Seeker)
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 && (body == nil || replayable) {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+
+			if err := c.authenticate(ctx, ref.Registry, scope, challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+
+		return nil, fmt.Errorf("%s %s: unexpected status %s", method, req.URL.Redacted(), resp.Status)
+	}
+
+	return nil, fmt.Errorf("unauthorized access to '%s'", ref.Name())
+}
+
+func (c *Client) newRequest(ctx context.Context, registry, method, target string, body io.Reader, contentType string) (*http.Request, error) {
+	if body == nil {
+		body = http.NoBody
+	}
+
+	u := target
+	if strings.HasPrefix(target, "/") {
+		u = c.scheme(registry) + "://" + registry + target
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if sr, ok := body.(*sizedReader); ok {
+		req.ContentLength = sr.size
+	}
+
+	if strings.Contains(target, "/manifests/") {
+		req.Header.Set("Accept", acceptedManifests)
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req, nil
+}
+
+func (c *Client) scheme(registry string) string {
+	host := registry
+	if idx := strings.LastIndex(host, ":"); idx != -1 {
+		host = host[:idx]
+	}
+
+	if c.PlainHTTP || host == "localhost" || host == "127.0.0.1" {
+		return "http"
+	}
+
+	return "https"
+}
+
+func (c *Client) token(registry, scope string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[registry+"|"+scope]
+}
+
+// authenticate fetches a bearer token as described by the registry
+// challenge (i.e. Bearer realm="...",service="...").
+func (c *Client) authenticate(ctx context.Context, registry, scope, challenge string) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("unauthorized access to '%s'", registry)
+	}
+
+	params := parseChallenge(challenge[len("bearer "):])
+	realm, err := url.Parse(params["realm"])
+	if err != nil || len(realm.Host) == 0 {
+		return fmt.Errorf("invalid authentication realm '%s'", params["realm"])
+	}
+
+	q := realm.Query()
+	if svc := params["service"]; len(svc) > 0 {
+		q.Set("service", svc)
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching token for '%s': unexpected status %s", registry, resp.Status)
+	}
+
+	res := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("decoding token for '%s': %w", registry, err)
+	}
+
+	tok := res.Token
+	if len(tok) == 0 {
+		tok = res.AccessToken
+	}
+
+	c.mu.Lock()
+	c.tokens[registry+"|"+scope] = tok
+	c.mu.Unlock()
+
+	return nil
+}
+
+// parseChallenge parses the key="value" pairs of a challenge; values
+// may contain commas (i.e. scope="repository:foo:pull,push").
+func parseChallenge(s string) map[string]string {
+	res := map[string]string{}
+
+	for len(s) > 0 {
+		idx := strings.Index(s, "=")
+		if idx == -1 {
+			break
+		}
+		key := strings.ToLower(strings.Trim(s[:idx], " ,"))
+		s = s[idx+1:]
+
+		val := s
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end == -1 {
+				val, s = s[1:], ""
+			} else {
+				val, s = s[1:end+1], s[end+2:]
+			}
+		} else if end := strings.Index(s, ","); end != -1 {
+			val, s = s[:end], s[end:]
+		} else {
+			s = ""
+		}
+
+		res[key] = val
+	}
+
+	return res
+}
+
+func manifestPath(ref Reference, id string) string {
+	return fmt.Sprintf("/v2/%s/manifests/%s", ref.Repository, id)
+}
+
+func blobPath(ref Reference, dgst string) string {
+	return fmt.Sprintf("/v2/%s/blobs/%s", ref.Repository, dgst)
+}
@@ -0,0 +1,195 @@
+package oci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func registryHost(t *testing.T, rawURL string) string {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
+
+func TestParseReference(t *testing.T) {
+	table := []struct {
+		in   string
+		want Reference
+	}{
+		{"xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0",
+			Reference{"xpkg.upbound.io", "crossplane-contrib/provider-helm", "v0.15.0", ""}},
+		{"crossplane-contrib/provider-helm",
+			Reference{DefaultRegistry, "crossplane-contrib/provider-helm", "latest", ""}},
+		{"localhost:5000/foo@sha256:abc",
+			Reference{"localhost:5000", "foo", "", "sha256:abc"}},
+		{"registry.corp/a/b:v1@sha256:abc",
+			Reference{"registry.corp", "a/b", "v1", "sha256:abc"}},
+	}
+
+	for i, tc := range table {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseReference(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("[%d] expected: %+v, got: %+v", i, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReferenceMirror(t *testing.T) {
+	ref, _ := ParseReference("xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0")
+
+	got, err := ref.Mirror("registry.corp/upbound/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "registry.corp/upbound/crossplane-contrib/provider-helm:v0.15.0"; got.String() != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	reg, srv := newTestRegistry(t)
+	want := reg.push("crossplane-contrib/provider-helm", "v0.15.0", "layer")
+
+	ref, _ := ParseReference(registryHost(t, srv.URL) + "/crossplane-contrib/provider-helm:v0.15.0")
+
+	got, err := NewClient().Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+}
+
+func TestExists(t *testing.T) {
+	reg, srv := newTestRegistry(t)
+	reg.token = "s3cr3t"
+	reg.push("foo", "v1", "layer")
+
+	host := registryHost(t, srv.URL)
+	c := NewClient()
+
+	table := []struct {
+		ref  string
+		want bool
+	}{
+		{host + "/foo:v1", true},
+		{host + "/foo:v2", false},
+		{host + "/bar:v1", false},
+	}
+
+	for _, tc := range table {
+		ref, _ := ParseReference(tc.ref)
+		got, err := c.Exists(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected: %v, got: %v", tc.ref, tc.want, got)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src, srcSrv := newTestRegistry(t)
+	want := src.push("crossplane-contrib/provider-helm", "v0.15.0", "layer")
+
+	dst, dstSrv := newTestRegistry(t)
+	dst.token = "s3cr3t"
+
+	srcRef, _ := ParseReference(registryHost(t, srcSrv.URL) + "/crossplane-contrib/provider-helm:v0.15.0")
+	dstRef, err := srcRef.Mirror(registryHost(t, dstSrv.URL) + "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient()
+
+	got, err := c.Copy(context.Background(), srcRef, dstRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+
+	got, err = c.Resolve(context.Background(), dstRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected mirrored digest: %s, got: %s", want, got)
+	}
+
+	if len(dst.blobs) != 2 {
+		t.Fatalf("expected 2 blobs in mirror, got: %d", len(dst.blobs))
+	}
+}
+
+func TestCopyUploadLocation(t *testing.T) {
+	src, srcSrv := newTestRegistry(t)
+	want := src.push("crossplane-contrib/provider-helm", "v0.15.0", "layer")
+
+	dst, dstSrv := newTestRegistry(t)
+	dst.token = "s3cr3t"
+
+	// the blobs are uploaded to a storage host, without the registry token
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.Header.Get("Authorization")) > 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		dst.mu.Lock()
+		defer dst.mu.Unlock()
+		dst.putBlob(w, req)
+	}))
+	defer storage.Close()
+	dst.uploadURL = storage.URL
+
+	srcRef, _ := ParseReference(registryHost(t, srcSrv.URL) + "/crossplane-contrib/provider-helm:v0.15.0")
+	dstRef, err := srcRef.Mirror(registryHost(t, dstSrv.URL) + "/mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewClient().Copy(context.Background(), srcRef, dstRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected: %s, got: %s", want, got)
+	}
+	if len(dst.blobs) != 2 {
+		t.Fatalf("expected 2 blobs uploaded to storage, got: %d", len(dst.blobs))
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	got := parseChallenge(`realm="https://auth.io/token",service="registry",scope="repository:foo:pull,push"`)
+
+	want := map[string]string{
+		"realm":   "https://auth.io/token",
+		"service": "registry",
+		"scope":   "repository:foo:pull,push",
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s: expected: %s, got: %s", k, v, got[k])
+		}
+	}
+}
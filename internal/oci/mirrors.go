@@ -0,0 +1,63 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mirrors maps a registry, optionally with a repository prefix
+// (i.e. xpkg.upbound.io or xpkg.upbound.io/crossplane-contrib),
+// to the registry prefix serving its images.
+type Mirrors map[string]string
+
+// ParseMirrors parses a list of mappings in the 'from=to' form.
+func ParseMirrors(all []string) (Mirrors, error) {
+	res := Mirrors{}
+	for _, el := range all {
+		parts := strings.SplitN(el, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 || len(strings.TrimSpace(parts[1])) == 0 {
+			return nil, fmt.Errorf("invalid image registry mapping '%s' (expected from=to)", el)
+		}
+
+		from := strings.TrimSuffix(strings.TrimSpace(parts[0]), "/")
+		res[from] = strings.TrimSuffix(strings.TrimSpace(parts[1]), "/")
+	}
+	return res, nil
+}
+
+// Rewrite returns the image moved to its mirror; the longest matching
+// prefix wins. Images without a matching mirror are returned unchanged.
+func (m Mirrors) Rewrite(image string) (string, error) {
+	if len(m) == 0 || len(image) == 0 {
+		return image, nil
+	}
+
+	ref, err := ParseReference(image)
+	if err != nil {
+		return image, err
+	}
+
+	name := ref.Name()
+
+	match := ""
+	for from := range m {
+		if (name == from || strings.HasPrefix(name, from+"/")) && len(from) > len(match) {
+			match = from
+		}
+	}
+	if len(match) == 0 {
+		return image, nil
+	}
+
+	res, err := ParseReference(m[match] + strings.TrimPrefix(name, match))
+	if err != nil {
+		return image, err
+	}
+	res.Tag, res.Digest = ref.Tag, ref.Digest
+
+	if len(res.Digest) > 0 && len(res.Tag) > 0 {
+		return fmt.Sprintf("%s:%s@%s", res.Name(), res.Tag, res.Digest), nil
+	}
+
+	return res.String(), nil
+}
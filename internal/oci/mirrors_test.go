@@ -0,0 +1,46 @@
+package oci
+
+import "testing"
+
+func TestMirrorsRewrite(t *testing.T) {
+	m, err := ParseMirrors([]string{
+		"xpkg.upbound.io=registry.corp/upbound",
+		"xpkg.upbound.io/platformnow=registry.corp/pnow/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		in   string
+		want string
+	}{
+		{"xpkg.upbound.io/crossplane-contrib/provider-helm:v0.15.0",
+			"registry.corp/upbound/crossplane-contrib/provider-helm:v0.15.0"},
+		{"crossplane-contrib/provider-helm:v0.15.0",
+			"registry.corp/upbound/crossplane-contrib/provider-helm:v0.15.0"},
+		{"xpkg.upbound.io/platformnow/core@sha256:abc",
+			"registry.corp/pnow/core@sha256:abc"},
+		{"xpkg.upbound.io/platformnowx/core:v1",
+			"registry.corp/upbound/platformnowx/core:v1"},
+		{"ghcr.io/foo/bar:v1", "ghcr.io/foo/bar:v1"},
+	}
+
+	for _, tc := range table {
+		got, err := m.Rewrite(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected: %s, got: %s", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestParseMirrorsInvalid(t *testing.T) {
+	for _, el := range []string{"xpkg.upbound.io", "=registry.corp", "xpkg.upbound.io="} {
+		if _, err := ParseMirrors([]string{el}); err == nil {
+			t.Fatalf("%s: expected error", el)
+		}
+	}
+}
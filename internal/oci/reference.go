@@ -0,0 +1,106 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultRegistry is used for references without registry host,
+	// as Crossplane does for package images.
+	DefaultRegistry = "xpkg.upbound.io"
+)
+
+// Reference points to an image in a registry, by tag or by digest.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference (i.e. registry/repo:tag or registry/repo@sha256:...).
+func ParseReference(s string) (Reference, error) {
+	res := Reference{}
+
+	if len(s) == 0 {
+		return res, fmt.Errorf("empty image reference")
+	}
+
+	if idx := strings.Index(s, "@"); idx != -1 {
+		res.Digest = s[idx+1:]
+		s = s[:idx]
+	}
+
+	if idx := strings.LastIndex(s, ":"); idx != -1 && idx > strings.LastIndex(s, "/") {
+		res.Tag = s[idx+1:]
+		s = s[:idx]
+	}
+
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) == 2 && isRegistryHost(parts[0]) {
+		res.Registry, res.Repository = parts[0], parts[1]
+	} else {
+		res.Registry, res.Repository = DefaultRegistry, s
+	}
+
+	if len(res.Repository) == 0 {
+		return res, fmt.Errorf("invalid image reference '%s'", s)
+	}
+
+	if len(res.Tag) == 0 && len(res.Digest) == 0 {
+		res.Tag = "latest"
+	}
+
+	return res, nil
+}
+
+func isRegistryHost(s string) bool {
+	return strings.ContainsAny(s, ".:") || s == "localhost"
+}
+
+// String returns the reference, preferring the digest over the tag.
+func (r Reference) String() string {
+	if len(r.Digest) > 0 {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// Name returns registry/repository.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// identifier returns the digest or the tag used in manifests URLs.
+func (r Reference) identifier() string {
+	if len(r.Digest) > 0 {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// WithDigest returns the reference pinned to digest.
+func (r Reference) WithDigest(digest string) Reference {
+	r.Digest = digest
+	return r
+}
+
+// Mirror returns the reference moved below the prefix (registry[/path]),
+// keeping repository, tag and digest.
+func (r Reference) Mirror(prefix string) (Reference, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	parts := strings.SplitN(prefix, "/", 2)
+	if len(parts[0]) == 0 {
+		return r, fmt.Errorf("invalid registry '%s'", prefix)
+	}
+
+	res := r
+	res.Registry = parts[0]
+	if len(parts) == 2 {
+		res.Repository = parts[1] + "/" + r.Repository
+	}
+
+	return res, nil
+}
@@ -0,0 +1,160 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry is an in-memory registry implementing the subset of the
+// distribution API used by the client.
+type testRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte // repo/digest -> content
+	types     map[string]string // digest -> media type
+	tags      map[string]string // repo:tag -> digest
+	uploads   int
+	token     string // when set, a bearer token is required
+	// uploadURL, when set, is the absolute upload location (i.e. a
+	// storage host) served by putBlob.
+	uploadURL string
+}
+
+var registryPathRE = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs)/(.+)$`)
+
+func newTestRegistry(t *testing.T) (*testRegistry, *httptest.Server) {
+	t.Helper()
+
+	reg := &testRegistry{
+		blobs:     map[string][]byte{},
+		manifests: map[string][]byte{},
+		types:     map[string]string{},
+		tags:      map[string]string{},
+	}
+
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+
+	return reg, srv
+}
+
+func testDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// push stores an image with a single layer and returns its digest.
+func (r *testRegistry) push(repo, tag, layer string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := []byte(`{"architecture":"amd64"}`)
+	r.blobs[testDigest(cfg)] = cfg
+	r.blobs[testDigest([]byte(layer))] = []byte(layer)
+
+	m := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"%s","size":%d},"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"%s","size":%d}]}`,
+		MediaTypeOCIManifest, testDigest(cfg), len(cfg), testDigest([]byte(layer)), len(layer)))
+
+	dgst := testDigest(m)
+	r.manifests[repo+"/"+dgst] = m
+	r.types[dgst] = MediaTypeOCIManifest
+	r.tags[repo+":"+tag] = dgst
+
+	return dgst
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		fmt.Fprintf(w, `{"token":"%s"}`, r.token)
+		return
+	}
+
+	if len(r.token) > 0 && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="test"`, req.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/blobs/uploads/") {
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("%s%supload-%d?state=%d", r.uploadURL, req.URL.Path, r.uploads, r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/blobs/uploads/") && len(r.uploadURL) == 0 {
+		r.putBlob(w, req)
+		return
+	}
+
+	m := registryPathRE.FindStringSubmatch(req.URL.Path)
+	if m == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repo, kind, id := m[1], m[2], m[3]
+
+	if kind == "blobs" {
+		data, ok := r.blobs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method == http.MethodGet {
+			w.Write(data)
+		}
+		return
+	}
+
+	if req.Method == http.MethodPut {
+		data, _ := io.ReadAll(req.Body)
+		dgst := testDigest(data)
+		r.manifests[repo+"/"+dgst] = data
+		r.types[dgst] = req.Header.Get("Content-Type")
+		if !strings.HasPrefix(id, "sha256:") {
+			r.tags[repo+":"+id] = dgst
+		}
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	dgst := id
+	if !strings.HasPrefix(id, "sha256:") {
+		dgst = r.tags[repo+":"+id]
+	}
+
+	data, ok := r.manifests[repo+"/"+dgst]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", r.types[dgst])
+	w.Header().Set(digestHeader, dgst)
+	if req.Method == http.MethodGet {
+		w.Write(data)
+	}
+}
+
+// putBlob stores an uploaded blob; the caller holds r.mu.
+func (r *testRegistry) putBlob(w http.ResponseWriter, req *http.Request) {
+	data, _ := io.ReadAll(req.Body)
+	dgst := req.URL.Query().Get("digest")
+	if testDigest(data) != dgst || len(req.URL.Query().Get("state")) == 0 || req.ContentLength != int64(len(data)) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.blobs[dgst] = data
+	w.WriteHeader(http.StatusCreated)
+}
@@ -23,7 +23,9 @@ type WatchOpts struct {
 	StopFunc  func(corev1.PodCondition) bool
 }
 
-func Watch(dc dynamic.Interface, opts WatchOpts) error {
+// Watch calls opts.StopFunc for the conditions of the added and modified pods
+// until it returns true or ctx is done (the context error).
+func Watch(ctx context.Context, dc dynamic.Interface, opts WatchOpts) error {
 	watchFn := func(_ metav1.ListOptions) (watch.Interface, error) {
 		timeoutSecs := int64(120)
 
@@ -31,7 +33,7 @@ func Watch(dc dynamic.Interface, opts WatchOpts) error {
 
 		return dc.Resource(gvr).
 			Namespace(opts.Namespace).
-			Watch(context.Background(), metav1.ListOptions{
+			Watch(ctx, metav1.ListOptions{
 				LabelSelector:  opts.Selector.String(),
 				TimeoutSeconds: &timeoutSecs,
 			})
@@ -52,9 +54,15 @@ func Watch(dc dynamic.Interface, opts WatchOpts) error {
 	// process incoming event notifications
 	for {
 		// grab the event object
-		event, ok := <-rw.ResultChan()
-		if !ok {
-			return fmt.Errorf("closed channel")
+		var event watch.Event
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-rw.ResultChan():
+			if !ok {
+				return fmt.Errorf("closed channel")
+			}
+			event = ev
 		}
 
 		if et := event.Type; et != watch.Added && et != watch.Modified {
@@ -0,0 +1,189 @@
+// Package rbac generates the least-privilege ClusterRoles of the providers
+// and audits the bindings created by lash.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/platfornow/lash/internal/clusterrolebindings"
+	"github.com/platfornow/lash/internal/core"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ModeAdmin binds the provider service accounts to cluster-admin,
+	// as published in the catalog.
+	ModeAdmin = "admin"
+	// ModeMinimal binds the provider service accounts to a ClusterRole
+	// generated from the catalog RBAC profile.
+	ModeMinimal = "minimal"
+
+	clusterAdmin = "cluster-admin"
+)
+
+var ClusterRoleGVK = schema.GroupVersionKind{
+	Group:   rbacv1.GroupName,
+	Version: "v1",
+	Kind:    "ClusterRole",
+}
+
+var ClusterRoleBindingGVK = schema.GroupVersionKind{
+	Group:   rbacv1.GroupName,
+	Version: "v1",
+	Kind:    "ClusterRoleBinding",
+}
+
+// ParseMode validates the RBAC mode.
+func ParseMode(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", ModeAdmin:
+		return ModeAdmin, nil
+	case ModeMinimal:
+		return ModeMinimal, nil
+	default:
+		return "", fmt.Errorf("invalid rbac mode '%s' (expected %s or %s)", s, ModeMinimal, ModeAdmin)
+	}
+}
+
+// ClusterRoleName returns the name of the ClusterRole generated for the package.
+func ClusterRoleName(pkg string) string {
+	return fmt.Sprintf("lash:%s:minimal", pkg)
+}
+
+// ClusterRole returns the ClusterRole granting the rules of the package profile.
+func ClusterRole(pkg string, rules []rbacv1.PolicyRule) (*unstructured.Unstructured, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no rbac profile for '%s'", pkg)
+	}
+
+	cr := rbacv1.ClusterRole{
+		Rules: rules,
+	}
+	cr.SetName(ClusterRoleName(pkg))
+	cr.SetLabels(map[string]string{
+		core.InstalledByLabel: core.InstalledByValue,
+		core.PackageNameLabel: pkg,
+	})
+
+	dat, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&cr)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{Object: dat}
+	obj.SetGroupVersionKind(ClusterRoleGVK)
+
+	return obj, nil
+}
+
+// Restrict makes the ClusterRoleBinding reference the ClusterRole generated
+// for the package instead of the role published in the catalog.
+func Restrict(binding *unstructured.Unstructured, pkg string) error {
+	return unstructured.SetNestedStringMap(binding.Object, map[string]string{
+		"apiGroup": rbacv1.GroupName,
+		"kind":     "ClusterRole",
+		"name":     ClusterRoleName(pkg),
+	}, "roleRef")
+}
+
+// DeleteIfRoleChanged deletes the ClusterRoleBinding from the cluster when it
+// references another role than binding (i.e. switching the RBAC mode): the
+// roleRef is immutable, so the binding must be created again.
+func DeleteIfRoleChanged(ctx context.Context, restConfig *rest.Config, binding *unstructured.Unstructured) error {
+	cur, err := core.Get(ctx, core.GetOpts{
+		RESTConfig: restConfig,
+		GVK:        ClusterRoleBindingGVK,
+		Name:       binding.GetName(),
+	})
+	if err != nil || cur == nil {
+		return err
+	}
+
+	if !roleChanged(cur, binding) {
+		return nil
+	}
+
+	return clusterrolebindings.Delete(ctx, clusterrolebindings.DeleteOpts{
+		RESTConfig: restConfig,
+		Name:       binding.GetName(),
+	})
+}
+
+// roleChanged compares the kind and name of the bindings roleRef, the
+// apiGroup being defaulted by the server.
+func roleChanged(cur, binding *unstructured.Unstructured) bool {
+	a, _, _ := unstructured.NestedStringMap(cur.Object, "roleRef")
+	b, _, _ := unstructured.NestedStringMap(binding.Object, "roleRef")
+	return a["kind"] != b["kind"] || a["name"] != b["name"]
+}
+
+// Finding is a lash created binding.
+type Finding struct {
+	Binding      string
+	Role         string
+	Subjects     []string
+	ClusterAdmin bool
+}
+
+// Audit returns the ClusterRoleBindings created by lash, or binding a
+// service account of the namespace, flagging the ones granting cluster-admin.
+func Audit(ctx context.Context, restConfig *rest.Config, namespace string) ([]Finding, error) {
+	all, err := clusterrolebindings.List(ctx, restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return findings(all, namespace)
+}
+
+func findings(all []unstructured.Unstructured, namespace string) ([]Finding, error) {
+	res := []Finding{}
+	for _, el := range all {
+		crb := rbacv1.ClusterRoleBinding{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(el.UnstructuredContent(), &crb)
+		if err != nil {
+			return nil, err
+		}
+
+		createdByLash := crb.GetLabels()[core.InstalledByLabel] == core.InstalledByValue
+
+		subjects := []string{}
+		for _, sub := range crb.Subjects {
+			if sub.Kind == rbacv1.ServiceAccountKind && sub.Namespace == namespace {
+				createdByLash = true
+			}
+			subjects = append(subjects, subjectName(sub))
+		}
+
+		if !createdByLash {
+			continue
+		}
+
+		res = append(res, Finding{
+			Binding:      crb.GetName(),
+			Role:         fmt.Sprintf("%s/%s", crb.RoleRef.Kind, crb.RoleRef.Name),
+			Subjects:     subjects,
+			ClusterAdmin: crb.RoleRef.Kind == "ClusterRole" && crb.RoleRef.Name == clusterAdmin,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Binding < res[j].Binding
+	})
+
+	return res, nil
+}
+
+func subjectName(sub rbacv1.Subject) string {
+	if len(sub.Namespace) > 0 {
+		return fmt.Sprintf("%s:%s/%s", sub.Kind, sub.Namespace, sub.Name)
+	}
+	return fmt.Sprintf("%s:%s", sub.Kind, sub.Name)
+}
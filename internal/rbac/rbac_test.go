@@ -0,0 +1,140 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/platfornow/lash/internal/core"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestParseMode(t *testing.T) {
+	table := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{"", ModeAdmin, false},
+		{"admin", ModeAdmin, false},
+		{"Minimal", ModeMinimal, false},
+		{"root", "", true},
+	}
+
+	for _, tc := range table {
+		got, err := ParseMode(tc.in)
+		if (err != nil) != tc.err {
+			t.Fatalf("%q: unexpected error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: expected: %s, got: %s", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestClusterRole(t *testing.T) {
+	if _, err := ClusterRole("provider-helm", nil); err == nil {
+		t.Fatal("expected error without rules")
+	}
+
+	obj, err := ClusterRole("provider-helm", []rbacv1.PolicyRule{
+		{APIGroups: []string{"helm.crossplane.io"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if obj.GetName() != ClusterRoleName("provider-helm") {
+		t.Fatalf("unexpected name: %s", obj.GetName())
+	}
+	if obj.GetLabels()[core.InstalledByLabel] != core.InstalledByValue {
+		t.Fatalf("expected installed-by label, got: %v", obj.GetLabels())
+	}
+
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "rules")
+	if len(rules) != 1 {
+		t.Fatalf("expected 1 rule, got: %d", len(rules))
+	}
+}
+
+func TestRestrictAndAudit(t *testing.T) {
+	binding := func(name, role string, lbls map[string]string, sub rbacv1.Subject) unstructured.Unstructured {
+		crb := rbacv1.ClusterRoleBinding{
+			RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role},
+			Subjects: []rbacv1.Subject{sub},
+		}
+		crb.SetName(name)
+		crb.SetLabels(lbls)
+
+		dat, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&crb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return unstructured.Unstructured{Object: dat}
+	}
+
+	lash := map[string]string{core.InstalledByLabel: core.InstalledByValue}
+	sa := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "provider-helm", Namespace: "landscape-system"}
+	other := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "foo", Namespace: "default"}
+
+	restricted := binding("provider-kubernetes", "cluster-admin", lash, other)
+	if err := Restrict(&restricted, "provider-kubernetes"); err != nil {
+		t.Fatal(err)
+	}
+
+	all := []unstructured.Unstructured{
+		binding("provider-helm-admin-binding", "cluster-admin", nil, sa),
+		restricted,
+		binding("unrelated", "cluster-admin", nil, other),
+	}
+
+	got, err := findings(all, "landscape-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 findings, got: %+v", got)
+	}
+
+	if got[0].Binding != "provider-helm-admin-binding" || !got[0].ClusterAdmin {
+		t.Fatalf("expected cluster-admin finding, got: %+v", got[0])
+	}
+
+	if got[1].Binding != "provider-kubernetes" || got[1].ClusterAdmin {
+		t.Fatalf("expected restricted finding, got: %+v", got[1])
+	}
+	if got[1].Role != "ClusterRole/"+ClusterRoleName("provider-kubernetes") {
+		t.Fatalf("unexpected role: %s", got[1].Role)
+	}
+}
+
+func TestRoleChanged(t *testing.T) {
+	binding := func(roleRef map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{"roleRef": roleRef}}
+	}
+
+	admin := binding(map[string]interface{}{"apiGroup": rbacv1.GroupName, "kind": "ClusterRole", "name": "cluster-admin"})
+
+	restricted := binding(map[string]interface{}{"apiGroup": rbacv1.GroupName, "kind": "ClusterRole", "name": "cluster-admin"})
+	if err := Restrict(restricted, "provider-helm"); err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		name    string
+		binding *unstructured.Unstructured
+		want    bool
+	}{
+		{"same role", binding(map[string]interface{}{"apiGroup": rbacv1.GroupName, "kind": "ClusterRole", "name": "cluster-admin"}), false},
+		{"defaulted apiGroup", binding(map[string]interface{}{"kind": "ClusterRole", "name": "cluster-admin"}), false},
+		{"restricted", restricted, true},
+		{"other kind", binding(map[string]interface{}{"apiGroup": rbacv1.GroupName, "kind": "Role", "name": "cluster-admin"}), true},
+	}
+
+	for _, tc := range table {
+		if got := roleChanged(admin, tc.binding); got != tc.want {
+			t.Fatalf("%s: expected: %v, got: %v", tc.name, tc.want, got)
+		}
+	}
+}
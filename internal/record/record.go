@@ -0,0 +1,119 @@
+// Package record keeps track of what lash installed in a cluster.
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap holding the install record.
+	ConfigMapName = "lash-install-record"
+
+	dataKey = "record.json"
+)
+
+var configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+// Package is an installed catalog entry.
+type Package struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Kind        string    `json:"kind"`
+	Image       string    `json:"image,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// Record lists the packages installed by lash in a namespace.
+type Record struct {
+	Namespace string    `json:"namespace"`
+	Packages  []Package `json:"packages"`
+}
+
+// Add inserts or replaces the package with the same name.
+func (r *Record) Add(pkg Package) {
+	if pkg.InstalledAt.IsZero() {
+		pkg.InstalledAt = time.Now().UTC()
+	}
+
+	for i, el := range r.Packages {
+		if el.Name == pkg.Name {
+			r.Packages[i] = pkg
+			return
+		}
+	}
+
+	r.Packages = append(r.Packages, pkg)
+}
+
+// Load returns the install record stored in the namespace; an empty
+// record if none has been saved yet.
+func Load(ctx context.Context, restConfig *rest.Config, namespace string) (*Record, error) {
+	res := &Record{Namespace: namespace, Packages: []Package{}}
+
+	obj, err := core.Get(ctx, core.GetOpts{
+		RESTConfig: restConfig,
+		GVK:        configMapGVK,
+		Name:       ConfigMapName,
+		Namespace:  namespace,
+	})
+	if err != nil || obj == nil {
+		return res, err
+	}
+
+	return res, decode(obj, res)
+}
+
+// Save stores the install record in its namespace.
+func Save(ctx context.Context, restConfig *rest.Config, rec *Record) error {
+	obj, err := configMap(rec)
+	if err != nil {
+		return err
+	}
+
+	return core.Apply(ctx, core.ApplyOpts{
+		RESTConfig: restConfig,
+		GVK:        configMapGVK,
+		Object:     obj,
+	})
+}
+
+// configMap returns the ConfigMap holding the record as JSON.
+func configMap(rec *Record) (*unstructured.Unstructured, error) {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(ConfigMapName)
+	obj.SetNamespace(rec.Namespace)
+	obj.SetLabels(map[string]string{
+		core.InstalledByLabel: core.InstalledByValue,
+	})
+
+	err = unstructured.SetNestedStringMap(obj.Object, map[string]string{
+		dataKey: string(data),
+	}, "data")
+	return obj, err
+}
+
+// decode reads the record of the ConfigMap into rec, left as is when the
+// ConfigMap has no record.
+func decode(obj *unstructured.Unstructured, rec *Record) error {
+	data, ok, err := unstructured.NestedString(obj.Object, "data", dataKey)
+	if err != nil || !ok {
+		return err
+	}
+
+	return json.Unmarshal([]byte(data), rec)
+}
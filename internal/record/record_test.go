@@ -0,0 +1,73 @@
+package record
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/platfornow/lash/internal/core"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRecordAdd(t *testing.T) {
+	installed := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	rec := &Record{Namespace: "landscape-system"}
+	rec.Add(Package{Name: "provider-helm", Version: "v0.18.0", Kind: "Provider", InstalledAt: installed})
+	rec.Add(Package{Name: "core", Version: "v1.0.0", Kind: "Configuration", InstalledAt: installed})
+
+	// same name, replaced in place
+	rec.Add(Package{Name: "provider-helm", Version: "v0.19.0", Kind: "Provider", InstalledAt: installed})
+
+	if len(rec.Packages) != 2 {
+		t.Fatalf("expected 2 packages, got: %+v", rec.Packages)
+	}
+	if rec.Packages[0].Name != "provider-helm" || rec.Packages[0].Version != "v0.19.0" {
+		t.Fatalf("expected the replaced package first, got: %+v", rec.Packages[0])
+	}
+	if rec.Packages[1].Name != "core" {
+		t.Fatalf("expected the appended package, got: %+v", rec.Packages[1])
+	}
+
+	before := time.Now().UTC()
+	rec.Add(Package{Name: "provider-kubernetes", Version: "v0.14.0", Kind: "Provider"})
+
+	got := rec.Packages[2].InstalledAt
+	if got.Before(before) || got.Location() != time.UTC {
+		t.Fatalf("expected the install time to default to now in UTC, got: %s", got)
+	}
+}
+
+func TestConfigMap(t *testing.T) {
+	rec := &Record{Namespace: "landscape-system"}
+	rec.Add(Package{
+		Name:        "provider-helm",
+		Version:     "v0.18.0",
+		Kind:        "Provider",
+		Image:       "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.18.0",
+		Source:      "default",
+		InstalledAt: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+	})
+
+	obj, err := configMap(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.GetName() != ConfigMapName || obj.GetNamespace() != "landscape-system" || obj.GetLabels()[core.InstalledByLabel] != core.InstalledByValue {
+		t.Fatalf("unexpected ConfigMap: %+v", obj.Object)
+	}
+
+	got := &Record{Namespace: "landscape-system", Packages: []Package{}}
+	if err := decode(obj, got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rec) {
+		t.Fatalf("expected %+v, got %+v", rec, got)
+	}
+
+	// a ConfigMap without the record leaves it empty
+	empty := &Record{Packages: []Package{}}
+	if err := decode(&unstructured.Unstructured{Object: map[string]interface{}{}}, empty); err != nil || len(empty.Packages) != 0 {
+		t.Fatalf("expected an empty record, got: %+v, %v", empty, err)
+	}
+}
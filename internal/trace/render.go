@@ -0,0 +1,60 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Render writes the tree as a table with status, age and last event of
+// each node; ages are relative to now.
+func Render(w io.Writer, n *Node, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSYNCED\tREADY\tAGE\tLAST EVENT\tMESSAGE")
+
+	render(tw, n, "", "", now)
+
+	return tw.Flush()
+}
+
+func render(w io.Writer, n *Node, prefix, indent string, now time.Time) {
+	synced, ready := valueOrNone(n.Synced), valueOrNone(n.Ready)
+	if n.Missing {
+		synced, ready = "Missing", "Missing"
+	}
+
+	age := "-"
+	if n.Created != nil {
+		age = duration.HumanDuration(now.Sub(*n.Created))
+	}
+
+	last, msg := "-", n.Message
+	if e := n.LastEvent; e != nil {
+		last = fmt.Sprintf("%s %s (%s ago)", e.Type, e.Reason, duration.HumanDuration(now.Sub(e.Time)))
+		if len(msg) == 0 && e.Type != "Normal" {
+			msg = e.Message
+		}
+	}
+
+	fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\n", prefix, n, synced, ready, age, last,
+		valueOrNone(strings.ReplaceAll(msg, "\n", " ")))
+
+	for i, el := range n.Children {
+		branch, next := "├─ ", "│  "
+		if i == len(n.Children)-1 {
+			branch, next = "└─ ", "   "
+		}
+		render(w, el, indent+branch, indent+next, now)
+	}
+}
+
+func valueOrNone(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
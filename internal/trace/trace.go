@@ -0,0 +1,308 @@
+// Package trace builds the tree of the objects behind a Crossplane claim or
+// composite: the composite, the composed resources, the provider-kubernetes
+// Objects and the Argo CD Applications they create.
+package trace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/platfornow/lash/internal/argocd"
+	"github.com/platfornow/lash/internal/claims"
+	"github.com/platfornow/lash/internal/core"
+	"github.com/platfornow/lash/internal/crossplane/managed"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// maxDepth bounds the composition nesting followed by Build.
+	maxDepth = 10
+
+	compositeLabel = "crossplane.io/composite"
+)
+
+// Event is the last event reported for an object.
+type Event struct {
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Node is an object of the trace tree.
+type Node struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Name       string     `json:"name"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Synced     string     `json:"synced,omitempty"`
+	Ready      string     `json:"ready,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	Created    *time.Time `json:"created,omitempty"`
+	LastEvent  *Event     `json:"lastEvent,omitempty"`
+	Missing    bool       `json:"missing,omitempty"`
+	Children   []*Node    `json:"children,omitempty"`
+}
+
+// String returns kind/name.
+func (n *Node) String() string {
+	return fmt.Sprintf("%s/%s", n.Kind, n.Name)
+}
+
+type Opts struct {
+	RESTConfig *rest.Config
+	// Kind of the root object as accepted by kubectl (i.e. core, cores.pkg.platformnow.io).
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+// Build returns the tree rooted at the named object.
+func Build(ctx context.Context, opts Opts) (*Node, error) {
+	res, err := core.ResolveAPIResource(core.ResolveAPIResourceOpts{
+		RESTConfig: opts.RESTConfig,
+		Query:      opts.Kind,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.Kind, err)
+	}
+
+	obj, err := core.GetByAPIResource(ctx, core.GetByAPIResourceOpts{
+		RESTConfig:  opts.RESTConfig,
+		APIResource: *res,
+		Name:        opts.Name,
+		Namespace:   opts.Namespace,
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%s '%s' not found", res.Kind, opts.Name)
+		}
+		return nil, err
+	}
+
+	objects, err := managed.ListK8Objects(ctx, opts.RESTConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := argocd.ListApplications(ctx, opts.RESTConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	evts, err := core.List(ctx, core.ListOpts{
+		RESTConfig: opts.RESTConfig,
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "Event"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTracer(objects, apps, evts)
+	t.get = func(ctx context.Context, ref claims.ObjectRef) (*unstructured.Unstructured, error) {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.Get(ctx, core.GetOpts{
+			RESTConfig: opts.RESTConfig,
+			GVK:        gv.WithKind(ref.Kind),
+			Name:       ref.Name,
+			Namespace:  ref.Namespace,
+		})
+	}
+
+	return t.walk(ctx, obj, 0)
+}
+
+// tracer walks the tree looking up the listed objects by index.
+type tracer struct {
+	get func(ctx context.Context, ref claims.ObjectRef) (*unstructured.Unstructured, error)
+
+	// objects by composite name
+	objects map[string][]unstructured.Unstructured
+	// applications by namespace/name
+	apps map[string]*unstructured.Unstructured
+	// last event by involved object uid
+	events map[types.UID]*Event
+}
+
+func newTracer(objects, apps, evts []unstructured.Unstructured) *tracer {
+	t := &tracer{
+		objects: map[string][]unstructured.Unstructured{},
+		apps:    map[string]*unstructured.Unstructured{},
+		events:  map[types.UID]*Event{},
+	}
+
+	for _, el := range objects {
+		if name, ok := el.GetLabels()[compositeLabel]; ok {
+			t.objects[name] = append(t.objects[name], el)
+		}
+	}
+
+	for i := range apps {
+		t.apps[apps[i].GetNamespace()+"/"+apps[i].GetName()] = &apps[i]
+	}
+
+	for _, el := range evts {
+		uid, _, _ := unstructured.NestedString(el.Object, "involvedObject", "uid")
+		evt := eventOf(&el)
+		if last, ok := t.events[types.UID(uid)]; !ok || evt.Time.After(last.Time) {
+			t.events[types.UID(uid)] = evt
+		}
+	}
+
+	return t
+}
+
+func (t *tracer) walk(ctx context.Context, obj *unstructured.Unstructured, depth int) (*Node, error) {
+	res := t.node(obj)
+	if depth >= maxDepth {
+		return res, nil
+	}
+
+	seen := map[types.UID]bool{}
+	for _, ref := range claims.ResourceRefs(obj) {
+		child, err := t.get(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			res.Children = append(res.Children, &Node{
+				APIVersion: ref.APIVersion,
+				Kind:       ref.Kind,
+				Name:       ref.Name,
+				Namespace:  ref.Namespace,
+				Missing:    true,
+			})
+			continue
+		}
+
+		seen[child.GetUID()] = true
+		node, err := t.walk(ctx, child, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		res.Children = append(res.Children, node)
+	}
+
+	// provider-kubernetes Objects composed but not referenced
+	for i, el := range t.objects[obj.GetName()] {
+		if seen[el.GetUID()] || el.GetUID() == obj.GetUID() {
+			continue
+		}
+		node, err := t.walk(ctx, &t.objects[obj.GetName()][i], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		res.Children = append(res.Children, node)
+	}
+
+	if app := t.application(obj); app != nil {
+		res.Children = append(res.Children, app)
+	}
+
+	return res, nil
+}
+
+func (t *tracer) node(obj *unstructured.Unstructured) *Node {
+	res := &Node{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		LastEvent:  t.events[obj.GetUID()],
+	}
+	if ts := obj.GetCreationTimestamp(); !ts.IsZero() {
+		res.Created = &ts.Time
+	}
+	res.Synced, res.Ready, res.Message = claims.Conditions(obj)
+
+	return res
+}
+
+// application returns the Argo CD Application created by a provider-kubernetes
+// Object, nil if the object is not such an Object.
+func (t *tracer) application(obj *unstructured.Unstructured) *Node {
+	if obj.GroupVersionKind().Group != "kubernetes.crossplane.io" || obj.GetKind() != "Object" {
+		return nil
+	}
+
+	manifest, ok, _ := unstructured.NestedMap(obj.Object, "spec", "forProvider", "manifest")
+	if !ok {
+		return nil
+	}
+
+	m := unstructured.Unstructured{Object: manifest}
+	if m.GroupVersionKind().Group != "argoproj.io" || m.GetKind() != "Application" {
+		return nil
+	}
+
+	app, ok := t.apps[m.GetNamespace()+"/"+m.GetName()]
+	if !ok {
+		return &Node{
+			APIVersion: m.GetAPIVersion(),
+			Kind:       m.GetKind(),
+			Name:       m.GetName(),
+			Namespace:  m.GetNamespace(),
+			Missing:    true,
+		}
+	}
+
+	res := t.node(app)
+
+	sync, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	health, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	res.Synced = argoStatus(sync, "Synced")
+	res.Ready = argoStatus(health, "Healthy")
+
+	messages := []string{}
+	if len(sync) > 0 && sync != "Synced" {
+		messages = append(messages, sync)
+	}
+	if len(health) > 0 && health != "Healthy" {
+		msg, _, _ := unstructured.NestedString(app.Object, "status", "health", "message")
+		messages = append(messages, strings.TrimSuffix(health+": "+msg, ": "))
+	}
+	res.Message = strings.Join(messages, "; ")
+
+	return res
+}
+
+// argoStatus maps an Argo CD status to a condition status.
+func argoStatus(status, ok string) string {
+	switch status {
+	case "":
+		return ""
+	case ok:
+		return "True"
+	case "Unknown":
+		return "Unknown"
+	default:
+		return "False"
+	}
+}
+
+func eventOf(obj *unstructured.Unstructured) *Event {
+	res := &Event{}
+	res.Type, _, _ = unstructured.NestedString(obj.Object, "type")
+	res.Reason, _, _ = unstructured.NestedString(obj.Object, "reason")
+	res.Message, _, _ = unstructured.NestedString(obj.Object, "message")
+
+	for _, field := range []string{"lastTimestamp", "eventTime", "firstTimestamp"} {
+		val, _, _ := unstructured.NestedString(obj.Object, field)
+		if ts, err := time.Parse(time.RFC3339, val); err == nil {
+			res.Time = ts
+			break
+		}
+	}
+
+	return res
+}
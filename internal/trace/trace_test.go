@@ -0,0 +1,151 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/platfornow/lash/internal/claims"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func object(apiVersion, kind, name, uid string, extra map[string]interface{}) unstructured.Unstructured {
+	obj := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"uid":               uid,
+			"creationTimestamp": "2026-10-19T10:00:00Z",
+		},
+	}
+	for k, v := range extra {
+		obj[k] = v
+	}
+	return unstructured.Unstructured{Object: obj}
+}
+
+func conditions(synced, ready, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Synced", "status": synced},
+			map[string]interface{}{"type": "Ready", "status": ready, "message": message},
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := object("pkg.platformnow.io/v1", "Core", "core", "1", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"resourceRefs": []interface{}{
+				map[string]interface{}{"apiVersion": "kubernetes.crossplane.io/v1alpha1", "kind": "Object", "name": "core-argo-app"},
+				map[string]interface{}{"apiVersion": "helm.crossplane.io/v1beta1", "kind": "Release", "name": "core-vault"},
+			},
+		},
+		"status": conditions("True", "False", "waiting"),
+	})
+
+	app := object("kubernetes.crossplane.io/v1alpha1", "Object", "core-argo-app", "2", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"forProvider": map[string]interface{}{
+				"manifest": map[string]interface{}{
+					"apiVersion": "argoproj.io/v1alpha1",
+					"kind":       "Application",
+					"metadata":   map[string]interface{}{"name": "platform", "namespace": "argocd"},
+				},
+			},
+		},
+		"status": conditions("True", "True", ""),
+	})
+
+	// composed by core but not referenced
+	extra := object("kubernetes.crossplane.io/v1alpha1", "Object", "core-extra", "3", map[string]interface{}{
+		"status": conditions("False", "False", "cannot apply"),
+	})
+	extra.SetLabels(map[string]string{compositeLabel: "core"})
+
+	argoApp := object("argoproj.io/v1alpha1", "Application", "platform", "4", map[string]interface{}{
+		"status": map[string]interface{}{
+			"sync":   map[string]interface{}{"status": "OutOfSync"},
+			"health": map[string]interface{}{"status": "Degraded", "message": "back-off"},
+		},
+	})
+	argoApp.SetNamespace("argocd")
+
+	evts := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"involvedObject": map[string]interface{}{"uid": "1"},
+			"type":           "Normal", "reason": "SelectComposition",
+			"lastTimestamp": "2026-10-19T10:01:00Z",
+		}},
+		{Object: map[string]interface{}{
+			"involvedObject": map[string]interface{}{"uid": "1"},
+			"type":           "Warning", "reason": "ComposeResources", "message": "cannot compose",
+			"lastTimestamp": "2026-10-19T10:05:00Z",
+		}},
+	}
+
+	tr := newTracer([]unstructured.Unstructured{app, extra}, []unstructured.Unstructured{argoApp}, evts)
+	tr.get = func(ctx context.Context, ref claims.ObjectRef) (*unstructured.Unstructured, error) {
+		if ref.Name == app.GetName() {
+			return &app, nil
+		}
+		return nil, nil
+	}
+
+	tree, err := tr.walk(context.Background(), &root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.LastEvent == nil || tree.LastEvent.Reason != "ComposeResources" {
+		t.Fatalf("expected the latest event, got: %+v", tree.LastEvent)
+	}
+
+	got := []string{}
+	for _, el := range tree.Children {
+		got = append(got, el.String())
+	}
+	if strings.Join(got, ",") != "Object/core-argo-app,Release/core-vault,Object/core-extra" {
+		t.Fatalf("unexpected children: %v", got)
+	}
+
+	if !tree.Children[1].Missing {
+		t.Fatal("expected missing release")
+	}
+
+	appNode := tree.Children[0].Children[0]
+	if appNode.String() != "Application/platform" || appNode.Synced != "False" || appNode.Ready != "False" {
+		t.Fatalf("unexpected application node: %+v", appNode)
+	}
+	if appNode.Message != "OutOfSync; Degraded: back-off" {
+		t.Fatalf("unexpected application message: %s", appNode.Message)
+	}
+
+	buf := bytes.Buffer{}
+	if err := Render(&buf, tree, time.Date(2026, 10, 19, 10, 10, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected header and 5 nodes, got:\n%s", buf.String())
+	}
+	for _, want := range []string{
+		"Core/core", "10m", "Warning ComposeResources (5m ago)", "waiting",
+	} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("expected %q in %q", want, lines[1])
+		}
+	}
+	if !strings.HasPrefix(lines[2], "├─ Object/core-argo-app") || !strings.HasPrefix(lines[3], "│  └─ Application/platform") {
+		t.Fatalf("unexpected tree:\n%s", buf.String())
+	}
+
+	if _, err := json.Marshal(tree); err != nil {
+		t.Fatal(err)
+	}
+}
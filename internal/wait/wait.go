@@ -0,0 +1,157 @@
+// Package wait runs the readiness waits of the installation phases with
+// configurable timeouts.
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrTimeout is returned (wrapped) by waits that did not complete in time.
+var ErrTimeout = errors.New("timed out")
+
+// Phases with a configurable timeout.
+const (
+	PhaseCrossplane     = "crossplane"
+	PhaseProviders      = "providers"
+	PhaseConfigurations = "configurations"
+	PhaseClaims         = "claims"
+)
+
+// Defaults are the phase timeouts used when not configured.
+var Defaults = map[string]time.Duration{
+	PhaseCrossplane:     5 * time.Minute,
+	PhaseProviders:      3 * time.Minute,
+	PhaseConfigurations: 2 * time.Minute,
+	PhaseClaims:         5 * time.Minute,
+}
+
+// Timeout returns d, or the default timeout of phase when d is not positive.
+func Timeout(d time.Duration, phase string) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return Defaults[phase]
+}
+
+// Timeouts are the configured timeouts: per phase overrides win over the
+// global timeout, which wins over the phase defaults.
+type Timeouts struct {
+	Global time.Duration
+	Phases map[string]time.Duration
+}
+
+// ParseTimeouts returns the timeouts from the global one and the phase
+// overrides as phase -> duration (i.e. claims -> 10m).
+func ParseTimeouts(global time.Duration, phases map[string]string) (Timeouts, error) {
+	res := Timeouts{
+		Global: global,
+		Phases: map[string]time.Duration{},
+	}
+
+	if global < 0 {
+		return res, fmt.Errorf("invalid timeout '%s': must be positive", global)
+	}
+
+	for phase, val := range phases {
+		if _, ok := Defaults[phase]; !ok {
+			return res, fmt.Errorf("unknown phase '%s' (%s)", phase, strings.Join(Phases(), ", "))
+		}
+
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return res, fmt.Errorf("invalid timeout for phase '%s': %w", phase, err)
+		}
+		if d <= 0 {
+			return res, fmt.Errorf("invalid timeout for phase '%s': must be positive", phase)
+		}
+		res.Phases[phase] = d
+	}
+
+	return res, nil
+}
+
+// For returns the timeout of phase.
+func (t Timeouts) For(phase string) time.Duration {
+	if d, ok := t.Phases[phase]; ok {
+		return d
+	}
+	return Timeout(t.Global, phase)
+}
+
+// Phases returns the sorted phase names.
+func Phases() []string {
+	res := make([]string, 0, len(Defaults))
+	for el := range Defaults {
+		res = append(res, el)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// TimeoutError reports what was not ready in time.
+type TimeoutError struct {
+	What    string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for %s", e.Timeout, e.What)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimeout
+}
+
+// For runs fn with a context expiring after timeout. When the wait times out
+// the error is a *TimeoutError describing what; the cancellation of ctx is
+// returned as the context error.
+func For(ctx context.Context, what string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	wctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(wctx)
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return &TimeoutError{What: what, Timeout: timeout}
+	}
+
+	return err
+}
+
+// Poll calls check every interval, starting immediately, until it returns
+// true, an error, or ctx is done.
+func Poll(ctx context.Context, interval time.Duration, check func(ctx context.Context) (bool, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := check(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimeoutsFor(t *testing.T) {
+	tests := []struct {
+		global time.Duration
+		phases map[string]string
+		phase  string
+		want   time.Duration
+	}{
+		{0, nil, PhaseConfigurations, 2 * time.Minute},
+		{10 * time.Minute, nil, PhaseConfigurations, 10 * time.Minute},
+		{10 * time.Minute, map[string]string{"claims": "20m"}, PhaseClaims, 20 * time.Minute},
+		{0, map[string]string{"claims": "20m"}, PhaseProviders, 3 * time.Minute},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			res, err := ParseTimeouts(tc.global, tc.phases)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.For(tc.phase); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTimeoutsErrors(t *testing.T) {
+	for _, phases := range []map[string]string{
+		{"unknown": "1m"},
+		{"claims": "soon"},
+		{"claims": "-1m"},
+	} {
+		if _, err := ParseTimeouts(0, phases); err == nil {
+			t.Fatalf("expected an error for %v", phases)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	err := For(context.Background(), "pods", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var te *TimeoutError
+	if !errors.As(err, &te) || !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected a timeout error, got: %v", err)
+	}
+	if err.Error() != "timed out after 10ms waiting for pods" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = For(ctx, "pods", time.Minute, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got: %v", err)
+	}
+
+	// fn reporting a timeout in its own words
+	err = For(context.Background(), "claim", time.Minute, func(context.Context) error {
+		return fmt.Errorf("claim not ready: %w", ErrTimeout)
+	})
+	if !errors.As(err, &te) || te.What != "claim" {
+		t.Fatalf("expected a timeout error for the wrapped ErrTimeout, got: %v", err)
+	}
+
+	boom := errors.New("boom")
+	if err := For(context.Background(), "pods", time.Minute, func(context.Context) error { return boom }); err != boom {
+		t.Fatalf("expected the fn error, got: %v", err)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	calls := 0
+	err := Poll(context.Background(), time.Millisecond, func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected 3 calls, got %d (%v)", calls, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Poll(ctx, time.Millisecond, func(context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got: %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/platfornow/lash/cmd"
 	"github.com/platfornow/lash/internal/log"
@@ -13,9 +17,28 @@ var (
 )
 
 func main() {
+	// the first Ctrl-C cancels the running command, a second one kills it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	ctl := cmd.LandscapeShell(Version, Build)
-	if err := ctl.Execute(); err != nil {
-		log.GetInstance().Error(err.Error())
+	if err := ctl.ExecuteContext(ctx); err != nil {
+		l := log.GetInstance()
+		l.StopWait()
+
+		var ie *cmd.InterruptedError
+		if ctx.Err() != nil && !errors.As(err, &ie) {
+			l.Errorf("interrupted: %s", err.Error())
+		} else {
+			l.Error(err.Error())
+		}
+
+		if ctx.Err() != nil {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }